@@ -2,4 +2,4 @@ module github.com/prorochestvo/rbacinjector
 
 go 1.22.4
 
-require github.com/twinj/uuid v1.0.0 // indirect
+require github.com/twinj/uuid v1.0.0
@@ -0,0 +1,132 @@
+package rbacinjector
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ErrRouteNotFound is returned when no registered pattern matches the method and the path.
+var ErrRouteNotFound = errors.New("route not found")
+
+// Roles returns all roles known to the router sorted by the name.
+// The roles are collected from the role registry, the role hierarchy, the permissions
+// and the roles of the registered routes.
+func (r *HttpRouter[T]) Roles() []Role[T] {
+	known := make(map[T]Role[T])
+	add := func(role Role[T]) {
+		if _, ok := known[role.ID()]; !ok {
+			known[role.ID()] = NamedRole[T]{id: role.ID(), name: r.registry.Format(role.ID())}
+		}
+	}
+
+	if r.registry != nil {
+		for _, role := range r.registry.Roles() {
+			add(role)
+		}
+	}
+	if r.hierarchy != nil {
+		for _, role := range r.hierarchy.roles {
+			add(role)
+		}
+	}
+	for _, id := range r.permissions.roles() {
+		add(NamedRole[T]{id: id})
+	}
+
+	for _, e := range r.routes() {
+		for _, role := range e.record.Roles {
+			add(role)
+		}
+		if e.record.policy != nil {
+			for _, role := range e.record.policy.roleSet() {
+				add(role)
+			}
+		}
+	}
+
+	roles := make([]Role[T], 0, len(known))
+	for _, role := range known {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].(NamedRole[T]).name < roles[j].(NamedRole[T]).name
+	})
+	return roles
+}
+
+// WhoCan returns the known roles that are granted access to the route matched by the method and the path.
+// The deny mode, the bitwise roles, the role hierarchy and the permissions are taken into account.
+// The custom Authorizer is evaluated with a synthetic request without headers and body.
+// It returns ErrRouteNotFound if no registered pattern matches the method and the path.
+func (r *HttpRouter[T]) WhoCan(method, path string) ([]Role[T], error) {
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, pattern := r.mux.Handler(req)
+	e, ok := r.routes()[pattern]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrRouteNotFound, method, path)
+	}
+
+	var roles []Role[T]
+	for _, role := range r.Roles() {
+		if r.can(req, e, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// WhatCan returns the records of the routes, that the role is granted access to.
+// The deny mode, the bitwise roles, the role hierarchy and the permissions are taken into account.
+// The custom Authorizer is evaluated with a synthetic request without headers and body.
+func (r *HttpRouter[T]) WhatCan(role Role[T]) []RouteRecord[T] {
+	records, access := r.access([]Role[T]{role})
+
+	var result []RouteRecord[T]
+	for i, record := range records {
+		if access[i][0] {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// access returns the records of all registered routes and, for each record, the access of every role.
+func (r *HttpRouter[T]) access(roles []Role[T]) ([]RouteRecord[T], [][]bool) {
+	records := r.Routes()
+	entries := r.routes()
+
+	access := make([][]bool, len(records))
+	for i, record := range records {
+		access[i] = make([]bool, len(roles))
+		path := record.Path
+		if path == "" || path[0] != '/' {
+			path = "/"
+		}
+		req, err := http.NewRequest(record.Method, path, nil)
+		if err != nil {
+			continue
+		}
+		for j, role := range roles {
+			access[i][j] = r.can(req, entries[record.Pattern], role)
+		}
+	}
+	return records, access
+}
+
+// can checks if the role is granted access to the registered route.
+// The public and the unprotected routes are granted to any role, except the unprotected routes in the strict mode.
+func (r *HttpRouter[T]) can(req *http.Request, e *routeEntry[T], role Role[T]) bool {
+	if e == nil || (r.strict && !e.record.Protected()) {
+		return false
+	}
+	if e.authorizer == nil {
+		return true
+	}
+	return e.authorizer.Authorize(req, []Role[T]{role}, e.record.RouteInfo).Allowed
+}
@@ -0,0 +1,115 @@
+package rbacinjector
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHttpRouter_WhoCan(t *testing.T) {
+	h := NewRoleHierarchy[uint64]()
+	if err := h.Inherit(iRoleAdmin, iRoleManager); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetRoleHierarchy(h)
+	registerStubRoles(t, router)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer, iRoleManager)
+	router.HandleFuncDenyFor("DELETE /accounts/{id}", httpStatusNoContent, iRoleCustomer, iRoleManager)
+	router.HandleFuncRequire("POST /accounts", httpStatusNoContent, permInvoiceWrite)
+	router.HandleFuncWithPolicy("GET /health", httpStatusNoContent, Public[uint64]())
+	router.Permissions().Grant(iRoleRoot, permInvoiceWrite)
+
+	cases := []struct {
+		method   string
+		path     string
+		expected []string
+	}{
+		{http.MethodGet, "/accounts/1", []string{"ADMIN", "CUSTOMER", "GUEST", "MANAGER"}},
+		{http.MethodDelete, "/accounts/1", []string{"ROOT"}},
+		{http.MethodPost, "/accounts", []string{"ROOT"}},
+		{http.MethodGet, "/health", []string{"ADMIN", "CUSTOMER", "GUEST", "MANAGER", "ROOT"}},
+	}
+	for _, c := range cases {
+		roles, err := router.WhoCan(c.method, c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names := roleNames(router, roles); !reflect.DeepEqual(names, c.expected) {
+			t.Errorf("unexpected roles %v for %s %s", names, c.method, c.path)
+		}
+	}
+
+	if _, err = router.WhoCan(http.MethodPut, "/accounts/1"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHttpRouter_WhatCan(t *testing.T) {
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncAllowFor("GET /tickets", httpStatusNoContent, sRoleSupport, sRoleAdmin)
+	router.HandleFuncDenyFor("POST /tickets", httpStatusNoContent, sRoleSupport)
+	router.HandleFuncWithPolicy("DELETE /tickets/{id}", httpStatusNoContent, AllOf[string](RoleSet[string](sRoleAdmin), Not[string](RoleSet[string](sRoleContractor))))
+	if err = router.HandleFunc("/legacy", httpStatusNoContent); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := func(records []RouteRecord[string]) []string {
+		var result []string
+		for _, r := range records {
+			result = append(result, r.Pattern)
+		}
+		return result
+	}
+	if p := patterns(router.WhatCan(sRoleSupport)); !reflect.DeepEqual(p, []string{"/legacy", "GET /tickets"}) {
+		t.Errorf("unexpected routes %v", p)
+	}
+	if p := patterns(router.WhatCan(sRoleAdmin)); !reflect.DeepEqual(p, []string{"/legacy", "GET /tickets", "POST /tickets", "DELETE /tickets/{id}"}) {
+		t.Errorf("unexpected routes %v", p)
+	}
+
+	router.SetStrictMode(true)
+	if p := patterns(router.WhatCan(sRoleContractor)); !reflect.DeepEqual(p, []string{"POST /tickets"}) {
+		t.Errorf("unexpected routes %v", p)
+	}
+}
+
+func TestHttpRouter_Roles(t *testing.T) {
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.Permissions().Grant(sRoleGuest, permInvoiceRead)
+	router.HandleFuncAllowFor("GET /tickets", httpStatusNoContent, sRoleSupport, sRoleAdmin)
+	router.HandleFuncWithPolicy("DELETE /tickets/{id}", httpStatusNoContent, Not[string](RoleSet[string](sRoleContractor, sRoleAdmin)))
+
+	if names := roleNames(router, router.Roles()); !reflect.DeepEqual(names, []string{"ADMIN", "CONTRACTOR", "GUEST", "SUPPORT"}) {
+		t.Errorf("unexpected roles %v", names)
+	}
+}
+
+func registerStubRoles(t *testing.T, router *HttpRouter[uint64]) {
+	g := NewRoleRegistry[uint64]()
+	for name, role := range map[string]Role[uint64]{"ROOT": iRoleRoot, "ADMIN": iRoleAdmin, "MANAGER": iRoleManager, "CUSTOMER": iRoleCustomer, "GUEST": iRoleGuest} {
+		if _, err := g.Register(role.ID(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router.SetRoleRegistry(g)
+}
+
+func roleNames[T RoleID](router *HttpRouter[T], roles []Role[T]) []string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, router.registry.Format(r.ID()))
+	}
+	return names
+}
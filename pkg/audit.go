@@ -0,0 +1,171 @@
+package rbacinjector
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord is the structured record of the authorization decision.
+type AuditRecord struct {
+	// Time is the time of the decision.
+	Time time.Time `json:"time"`
+	// Method is the method of the request.
+	Method string `json:"method"`
+	// Path is the path of the request.
+	Path string `json:"path"`
+	// Pattern is the matched pattern, it is empty for the wrapped handler.
+	Pattern string `json:"pattern"`
+	// RoleIDs are the IDs of the roles of the subject.
+	RoleIDs []string `json:"role_ids,omitempty"`
+	// RoleNames are the names of the roles of the subject formatted by the role registry.
+	RoleNames []string `json:"role_names,omitempty"`
+	// Allowed is true, if the subject is granted access to the route.
+	Allowed bool `json:"allowed"`
+	// Reason explains the decision.
+	Reason Reason `json:"reason"`
+	// Status is the HTTP status code of the decision.
+	Status int `json:"status"`
+	// Latency is the time spent to make the decision, in nanoseconds.
+	Latency time.Duration `json:"latency"`
+}
+
+// AuditHook is the interface that wraps the basic Audit method.
+// The Audit method receives the record of every decision made by the HttpRouter,
+// it is called before the request is served or rejected.
+type AuditHook interface {
+	Audit(ctx context.Context, record AuditRecord)
+}
+
+// AuditHookFunc is an adapter to allow the use of ordinary functions as AuditHook.
+type AuditHookFunc func(ctx context.Context, record AuditRecord)
+
+// Audit calls f(ctx, record).
+func (f AuditHookFunc) Audit(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// SetAuditHook sets the hook that receives the record of every decision.
+func (r *HttpRouter[T]) SetAuditHook(hook AuditHook) {
+	r.auditHook = hook
+}
+
+// observe passes the decision made for the request to the audit hook, the metrics and the span exporter.
+// The end of the span is the time the decision is observed, so the slow audit hook does not stretch the span.
+func (r *HttpRouter[T]) observe(req *http.Request, d Decision[T], latency time.Duration) {
+	end := time.Now()
+	if hook := r.auditHook; hook != nil {
+		hook.Audit(req.Context(), r.auditRecord(req, d, latency))
+	}
+	if m := r.metrics; m != nil {
+		m.observeDecision(d.Route.Pattern, req.Method, r.roleLabel(d.Roles), d.Status())
+	}
+	r.trace(req, d, end.Add(-latency), end)
+}
+
+// observed checks if the decisions are passed to the audit hook, the metrics or the span exporter.
+func (r *HttpRouter[T]) observed() bool {
+	return r.auditHook != nil || r.metrics != nil || r.spanExporter != nil
+}
+
+// auditRecord returns the audit record of the decision.
+func (r *HttpRouter[T]) auditRecord(req *http.Request, d Decision[T], latency time.Duration) AuditRecord {
+	record := AuditRecord{
+		Time:    time.Now(),
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Pattern: d.Route.Pattern,
+		Allowed: d.Allowed,
+		Reason:  d.Reason,
+		Status:  d.Status(),
+		Latency: latency,
+	}
+	for _, role := range d.Roles {
+		record.RoleIDs = append(record.RoleIDs, formatRoleID(role.ID()))
+		record.RoleNames = append(record.RoleNames, r.registry.Format(role.ID()))
+	}
+	return record
+}
+
+// SlogAuditHook returns an AuditHook that writes the records to the logger.
+// The allowed decisions are logged at the info level, the rejected decisions at the warn level.
+func SlogAuditHook(logger *slog.Logger) AuditHook {
+	return AuditHookFunc(func(ctx context.Context, record AuditRecord) {
+		level := slog.LevelInfo
+		if !record.Allowed {
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(ctx, level, "authorization decision",
+			slog.Time("time", record.Time),
+			slog.String("method", record.Method),
+			slog.String("path", record.Path),
+			slog.String("pattern", record.Pattern),
+			slog.Any("role_ids", record.RoleIDs),
+			slog.Any("role_names", record.RoleNames),
+			slog.Bool("allowed", record.Allowed),
+			slog.String("reason", string(record.Reason)),
+			slog.Int("status", record.Status),
+			slog.Duration("latency", record.Latency),
+		)
+	})
+}
+
+// NewJSONLinesAuditSink returns a new JSONLinesAuditSink that writes to the writer.
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	s := &JSONLinesAuditSink{
+		encoder: json.NewEncoder(w),
+	}
+	return s
+}
+
+// OpenJSONLinesAuditFile returns a new JSONLinesAuditSink that appends to the named file.
+// The file is created, if it does not exist.
+func OpenJSONLinesAuditFile(name string) (*JSONLinesAuditSink, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := NewJSONLinesAuditSink(f)
+	s.closer = f
+	return s, nil
+}
+
+// JSONLinesAuditSink is an AuditHook that writes every record as a line of JSON.
+// It is safe for concurrent use.
+type JSONLinesAuditSink struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+	err     error
+}
+
+// Audit writes the record as a line of JSON.
+func (s *JSONLinesAuditSink) Audit(_ context.Context, record AuditRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.encoder.Encode(record); err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// Err returns the first error of the writes.
+func (s *JSONLinesAuditSink) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// Close closes the file of the sink opened by OpenJSONLinesAuditFile.
+func (s *JSONLinesAuditSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
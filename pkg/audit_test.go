@@ -0,0 +1,166 @@
+package rbacinjector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_SetAuditHook(t *testing.T) {
+	var records []AuditRecord
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.SetAuditHook(AuditHookFunc(func(_ context.Context, record AuditRecord) {
+		records = append(records, record)
+	}))
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	router.HandleFuncDenyFor("DELETE /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	router.HandleFuncWithPolicy("GET /health", httpStatusNoContent, Public[uint64]())
+
+	cases := []struct {
+		method string
+		path   string
+		role   Role[uint64]
+	}{
+		{http.MethodGet, "/accounts/1", iRoleCustomer},
+		{http.MethodGet, "/accounts/1", nil},
+		{http.MethodDelete, "/accounts/1", iRoleCustomer},
+		{http.MethodGet, "/health", iRoleAdmin},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := []struct {
+		pattern string
+		roles   string
+		allowed bool
+		reason  Reason
+		status  int
+	}{
+		{"GET /accounts/{id}", "CUSTOMER", true, ReasonGranted, http.StatusOK},
+		{"GET /accounts/{id}", "", false, ReasonNoRole, http.StatusUnauthorized},
+		{"DELETE /accounts/{id}", "CUSTOMER", false, ReasonInDenySet, http.StatusForbidden},
+		{"GET /health", "ADMIN", true, ReasonPublic, http.StatusOK},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("unexpected records %v", records)
+	}
+	for i, e := range expected {
+		record := records[i]
+		if record.Pattern != e.pattern || strings.Join(record.RoleNames, ",") != e.roles ||
+			record.Allowed != e.allowed || record.Reason != e.reason || record.Status != e.status {
+			t.Errorf("unexpected record %+v", record)
+		}
+		if record.Time.IsZero() || record.Method != cases[i].method || record.Path != cases[i].path {
+			t.Errorf("unexpected record %+v", record)
+		}
+	}
+	if ids := strings.Join(records[0].RoleIDs, ","); ids != formatRoleID(iRoleCustomer.ID()) {
+		t.Errorf("unexpected role ids %s", ids)
+	}
+}
+
+func TestHttpRouter_PublicWithoutObserver(t *testing.T) {
+	var calls int
+	router, err := NewHttpRouter[uint64](func(r *http.Request) (Role[uint64], bool) {
+		calls++
+		return extractorINT(r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncWithPolicy("GET /health", httpStatusNoContent, Public[uint64]())
+	router.HandleFunc("GET /static", httpStatusNoContent)
+
+	for _, path := range []string{"/health", "/static"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNoContent {
+			t.Errorf("unexpected status code %d", w.Code)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("unexpected extractor calls %d", calls)
+	}
+
+	router.SetAuditHook(AuditHookFunc(func(context.Context, AuditRecord) {}))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if calls != 1 {
+		t.Errorf("unexpected extractor calls %d", calls)
+	}
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := OpenJSONLinesAuditFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetAuditHook(sink)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	for _, role := range []Role[uint64]{iRoleCustomer, iRoleAdmin} {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, role))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if err = sink.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var allowed []bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		allowed = append(allowed, record.Allowed)
+	}
+	if len(allowed) != 2 || !allowed[0] || allowed[1] {
+		t.Errorf("unexpected records %v", allowed)
+	}
+}
+
+func TestSlogAuditHook(t *testing.T) {
+	var buf bytes.Buffer
+	hook := SlogAuditHook(slog.New(slog.NewJSONHandler(&buf, nil)))
+	hook.Audit(context.Background(), AuditRecord{Method: http.MethodGet, Pattern: "GET /accounts/{id}", Reason: ReasonNotInAllowSet, Status: http.StatusForbidden})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "WARN" || entry["pattern"] != "GET /accounts/{id}" || entry["reason"] != string(ReasonNotInAllowSet) {
+		t.Errorf("unexpected entry %v", entry)
+	}
+}
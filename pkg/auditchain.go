@@ -0,0 +1,291 @@
+package rbacinjector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrAuditChainBroken is returned when the hash-chained audit log is deleted, reordered or modified.
+var ErrAuditChainBroken = errors.New("audit chain broken")
+
+// OpenHashChainAuditFile returns a new HashChainAuditSink that appends to the named file.
+// The file is created, if it does not exist, otherwise the chain is continued from its last line.
+// The checkpoint signed by the key is written after every checkpointEvery records and on Close.
+// It returns an error, if the key is not an ed25519 private key.
+func OpenHashChainAuditFile(name string, key ed25519.PrivateKey, checkpointEvery int) (*HashChainAuditSink, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("audit chain: invalid ed25519 private key length %d", len(key))
+	}
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &HashChainAuditSink{
+		file:            f,
+		key:             key,
+		checkpointEvery: checkpointEvery,
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	var last []byte
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+			var link chainLink
+			if json.Unmarshal(last, &link) == nil && link.Checkpoint != nil {
+				s.anchor = AuditChainAnchor{Seq: link.Seq, Hash: link.Hash}
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if last != nil {
+		var link chainLink
+		if err = json.Unmarshal(last, &link); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("%w: last line: %v", ErrAuditChainBroken, err)
+		}
+		if s.prev, err = hex.DecodeString(link.Hash); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("%w: last line: %v", ErrAuditChainBroken, err)
+		}
+		s.seq = link.Seq
+	}
+	return s, nil
+}
+
+// HashChainAuditSink is an AuditHook that writes every record as a line of JSON,
+// chained to the SHA-256 hash of the previous line. The periodic checkpoints are signed by ed25519,
+// so any deleted, reordered or modified line is detected by VerifyAuditChain.
+// The lines cut off the end of the file, even with their checkpoints, leave a valid chain,
+// so the truncation is detected only against the Anchor kept outside the file.
+// It is safe for concurrent use.
+type HashChainAuditSink struct {
+	mutex           sync.Mutex
+	file            *os.File
+	key             ed25519.PrivateKey
+	checkpointEvery int
+	seq             uint64
+	prev            []byte
+	unsigned        int
+	anchor          AuditChainAnchor
+	err             error
+}
+
+// AuditChainAnchor is the sequence number and the hash of the checkpoint of the hash-chained audit log.
+// The anchor kept outside the log, e.g. in another store, detects the truncation of the log by VerifyAuditChain.
+type AuditChainAnchor struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Anchor returns the anchor of the last checkpoint written to the file, it is zero before the first checkpoint.
+func (s *HashChainAuditSink) Anchor() AuditChainAnchor {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.anchor
+}
+
+// Audit writes the record as the next link of the chain.
+func (s *HashChainAuditSink) Audit(_ context.Context, record AuditRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body, err := json.Marshal(record)
+	if err == nil {
+		err = s.write(chainLink{Record: body})
+	}
+	if err == nil {
+		if s.unsigned++; s.checkpointEvery > 0 && s.unsigned >= s.checkpointEvery {
+			err = s.checkpoint()
+		}
+	}
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// Err returns the first error of the writes.
+func (s *HashChainAuditSink) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// Close writes the final checkpoint, if any record is not signed yet, and closes the file.
+func (s *HashChainAuditSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var err error
+	if s.unsigned > 0 {
+		err = s.checkpoint()
+	}
+	return errors.Join(err, s.file.Close())
+}
+
+// checkpoint writes the checkpoint link signed by the key.
+func (s *HashChainAuditSink) checkpoint() error {
+	body, err := json.Marshal(chainCheckpoint{Time: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	if err = s.write(chainLink{Checkpoint: body}); err != nil {
+		return err
+	}
+	s.unsigned = 0
+	s.anchor = AuditChainAnchor{Seq: s.seq, Hash: hex.EncodeToString(s.prev)}
+	return nil
+}
+
+// write completes the link by the sequence number, the hashes and the signature of the checkpoint, then writes it.
+func (s *HashChainAuditSink) write(link chainLink) error {
+	link.Seq = s.seq + 1
+	link.Prev = hex.EncodeToString(s.prev)
+	hash := link.hash(s.prev)
+	link.Hash = hex.EncodeToString(hash)
+	if link.Checkpoint != nil {
+		link.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, hash))
+	}
+
+	line, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.seq, s.prev = link.Seq, hash
+	return nil
+}
+
+// AuditChainStatus is the result of VerifyAuditChain.
+type AuditChainStatus struct {
+	// Records is the number of the verified records.
+	Records int
+	// Checkpoints is the number of the verified checkpoints.
+	Checkpoints int
+	// Unsigned is the number of the records after the last checkpoint,
+	// the truncation of these records can not be detected.
+	Unsigned int
+	// Anchor is the anchor of the last verified checkpoint, the caller should compare it
+	// with the anchor kept outside the log.
+	Anchor AuditChainAnchor
+}
+
+// VerifyAuditChain verifies the hash-chained audit log written by HashChainAuditSink.
+// It checks the sequence numbers, the links to the previous hashes, the hashes of the lines
+// and the signatures of the checkpoints by the public key.
+// It returns ErrAuditChainBroken with the line of the first deleted, reordered or modified line.
+// The lines cut off the end of the log can not be detected by the log itself, so the anchors
+// kept outside the log, e.g. the last HashChainAuditSink.Anchor, must be found in the log,
+// otherwise ErrAuditChainBroken is returned.
+func VerifyAuditChain(rd io.Reader, key ed25519.PublicKey, anchors ...AuditChainAnchor) (AuditChainStatus, error) {
+	var status AuditChainStatus
+	var prev []byte
+	var seq uint64
+	pending := make(map[uint64]string, len(anchors))
+	for _, a := range anchors {
+		pending[a.Seq] = a.Hash
+	}
+
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		broken := func(format string, args ...interface{}) (AuditChainStatus, error) {
+			return status, fmt.Errorf("%w: line %d: %s", ErrAuditChainBroken, line, fmt.Sprintf(format, args...))
+		}
+
+		var link chainLink
+		if err := json.Unmarshal(scanner.Bytes(), &link); err != nil {
+			return broken("%v", err)
+		}
+		switch {
+		case link.Seq != seq+1:
+			return broken("sequence %d, expected %d", link.Seq, seq+1)
+		case link.Prev != hex.EncodeToString(prev):
+			return broken("previous hash mismatch")
+		case (link.Record == nil) == (link.Checkpoint == nil):
+			return broken("neither a record nor a checkpoint")
+		}
+		hash := link.hash(prev)
+		if link.Hash != hex.EncodeToString(hash) {
+			return broken("hash mismatch")
+		}
+
+		if link.Checkpoint != nil {
+			signature, err := base64.StdEncoding.DecodeString(link.Signature)
+			if err != nil || !ed25519.Verify(key, hash, signature) {
+				return broken("invalid checkpoint signature")
+			}
+			status.Checkpoints++
+			status.Unsigned = 0
+			status.Anchor = AuditChainAnchor{Seq: link.Seq, Hash: link.Hash}
+		} else {
+			status.Records++
+			status.Unsigned++
+		}
+		if h, ok := pending[link.Seq]; ok {
+			if h != link.Hash {
+				return broken("anchor hash mismatch")
+			}
+			delete(pending, link.Seq)
+		}
+		seq, prev = link.Seq, hash
+	}
+	if err := scanner.Err(); err != nil {
+		return status, err
+	}
+	if len(pending) > 0 {
+		return status, fmt.Errorf("%w: anchor not found, the log is truncated at sequence %d", ErrAuditChainBroken, seq)
+	}
+	return status, nil
+}
+
+// chainLink is the line of the hash-chained audit log, it holds either a record or a checkpoint.
+type chainLink struct {
+	Seq        uint64          `json:"seq"`
+	Prev       string          `json:"prev"`
+	Record     json.RawMessage `json:"record,omitempty"`
+	Checkpoint json.RawMessage `json:"checkpoint,omitempty"`
+	Hash       string          `json:"hash"`
+	Signature  string          `json:"signature,omitempty"`
+}
+
+// chainCheckpoint is the body of the checkpoint.
+type chainCheckpoint struct {
+	Time time.Time `json:"time"`
+}
+
+// hash returns the SHA-256 hash of the previous hash, the sequence number, the kind and the body of the link.
+func (l chainLink) hash(prev []byte) []byte {
+	kind, body := byte('r'), l.Record
+	if l.Checkpoint != nil {
+		kind, body = 'c', l.Checkpoint
+	}
+	h := sha256.New()
+	h.Write(prev)
+	_ = binary.Write(h, binary.BigEndian, l.Seq)
+	h.Write([]byte{kind})
+	h.Write(body)
+	return h.Sum(nil)
+}
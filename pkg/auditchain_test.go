@@ -0,0 +1,119 @@
+package rbacinjector
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHashChainAuditSink(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, err := OpenHashChainAuditFile(name, private, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"GET /a", "GET /b", "GET /c"} {
+		sink.Audit(context.Background(), AuditRecord{Method: http.MethodGet, Pattern: pattern, Allowed: true, Reason: ReasonGranted})
+	}
+	if err = sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sink, err = OpenHashChainAuditFile(name, private, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anchor := sink.Anchor(); anchor.Seq != 5 {
+		t.Errorf("unexpected anchor %+v", anchor)
+	}
+	sink.Audit(context.Background(), AuditRecord{Method: http.MethodGet, Pattern: "GET /d", Reason: ReasonNotInAllowSet})
+	if err = sink.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	anchor := sink.Anchor()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := VerifyAuditChain(strings.NewReader(string(data)), public, anchor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != (AuditChainStatus{Records: 4, Checkpoints: 3, Unsigned: 0, Anchor: anchor}) || anchor.Seq != 7 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	join := func(lines ...string) string { return strings.Join(lines, "\n") + "\n" }
+	cases := []struct {
+		name string
+		log  string
+		line int
+	}{
+		{"modified", join(append([]string{lines[0], strings.Replace(lines[1], "GET /b", "GET /x", 1)}, lines[2:]...)...), 2},
+		{"deleted", join(append([]string{lines[0]}, lines[2:]...)...), 2},
+		{"reordered", join(append([]string{lines[1], lines[0]}, lines[2:]...)...), 1},
+		{"forged checkpoint", join(append(lines[:2:2], strings.Replace(lines[2], `"signature":"`, `"signature":"A`, 1))...), 3},
+	}
+	for _, c := range cases {
+		_, err = VerifyAuditChain(strings.NewReader(c.log), public)
+		if !errors.Is(err, ErrAuditChainBroken) {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		} else if !strings.Contains(err.Error(), fmt.Sprintf("line %d:", c.line)) {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+	}
+
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = VerifyAuditChain(strings.NewReader(string(data)), other); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	status, err = VerifyAuditChain(strings.NewReader(join(lines[:4]...)), public)
+	if err != nil || status.Unsigned != 1 {
+		t.Errorf("unexpected status %+v %v", status, err)
+	}
+
+	truncated := join(lines[:5]...)
+	if status, err = VerifyAuditChain(strings.NewReader(truncated), public); err != nil || status.Anchor.Seq != 5 {
+		t.Errorf("unexpected status %+v %v", status, err)
+	}
+	if _, err = VerifyAuditChain(strings.NewReader(truncated), public, anchor); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("unexpected error %v", err)
+	}
+	forged := AuditChainAnchor{Seq: 5, Hash: anchor.Hash}
+	if _, err = VerifyAuditChain(strings.NewReader(string(data)), public, forged); !errors.Is(err, ErrAuditChainBroken) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestOpenHashChainAuditFile_InvalidKey(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.log")
+	for _, key := range []ed25519.PrivateKey{nil, make(ed25519.PrivateKey, 10)} {
+		if sink, err := OpenHashChainAuditFile(name, key, 1); err == nil {
+			_ = sink.Close()
+			t.Errorf("expected error for the key of length %d", len(key))
+		}
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("unexpected file %v", err)
+	}
+}
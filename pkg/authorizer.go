@@ -0,0 +1,173 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Authorizer is the interface that wraps the basic Authorize method.
+// The Authorize method decides if the subject with the roles is granted access to the route.
+// The roles are never empty, the subject without roles is rejected before the Authorizer is called.
+type Authorizer[T RoleID] interface {
+	Authorize(r *http.Request, roles []Role[T], route RouteInfo) Decision[T]
+}
+
+// AuthorizerFunc is an adapter to allow the use of ordinary functions as Authorizer.
+type AuthorizerFunc[T RoleID] func(r *http.Request, roles []Role[T], route RouteInfo) Decision[T]
+
+// Authorize calls f(r, roles, route).
+func (f AuthorizerFunc[T]) Authorize(r *http.Request, roles []Role[T], route RouteInfo) Decision[T] {
+	return f(r, roles, route)
+}
+
+// RouteInfo is the metadata of the route, that is protected by the Authorizer.
+type RouteInfo struct {
+	// Pattern is the pattern the handler is registered for.
+	Pattern string `json:"pattern"`
+	// Method is the method of the pattern, it is empty for any method.
+	Method string `json:"method,omitempty"`
+	// Path is the host and the path of the pattern.
+	Path string `json:"path"`
+}
+
+// newRouteInfo returns a new RouteInfo based on the pattern.
+func newRouteInfo(pattern string) RouteInfo {
+	info := RouteInfo{Pattern: pattern, Path: strings.TrimSpace(pattern)}
+	if parts := strings.SplitN(info.Path, " ", 2); len(parts) == 2 {
+		info.Method = strings.TrimSpace(parts[0])
+		info.Path = strings.TrimSpace(parts[1])
+	}
+	return info
+}
+
+// Decision is the structured result of the Authorizer.
+type Decision[T RoleID] struct {
+	// Allowed is true, if the subject is granted access to the route.
+	Allowed bool
+	// Reason explains the decision.
+	Reason Reason
+	// Roles are the roles of the subject.
+	Roles []Role[T]
+	// Required are the roles of the allow or the deny set, that are applied to the subject.
+	Required []Role[T]
+	// Route is the route the decision is made for.
+	Route RouteInfo
+}
+
+// Status returns the HTTP status code of the decision.
+func (d Decision[T]) Status() int {
+	switch {
+	case d.Allowed:
+		return http.StatusOK
+	case d.Reason == ReasonNoRole:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusForbidden
+	}
+}
+
+// Reason is the reason of the Decision.
+type Reason string
+
+const (
+	// ReasonGranted is the reason of the allowed decision.
+	ReasonGranted Reason = "granted"
+	// ReasonNoRole is the reason of the decision for the subject without roles.
+	ReasonNoRole Reason = "no role"
+	// ReasonNotInAllowSet is the reason of the decision for the subject whose roles are not in the allow set.
+	ReasonNotInAllowSet Reason = "not in allow set"
+	// ReasonInDenySet is the reason of the decision for the subject whose roles are in the deny set.
+	ReasonInDenySet Reason = "in deny set"
+	// ReasonPolicyNotSatisfied is the reason of the decision for the subject whose roles do not satisfy the policy.
+	ReasonPolicyNotSatisfied Reason = "policy not satisfied"
+	// ReasonNoPolicy is the reason of the decision for the route without a policy in the strict mode.
+	ReasonNoPolicy Reason = "no policy"
+	// ReasonMissingPermission is the reason of the decision for the subject that is not granted the permissions.
+	ReasonMissingPermission Reason = "missing permission"
+	// ReasonPublic is the reason of the decision for the route with the Public policy.
+	ReasonPublic Reason = "public"
+	// ReasonUnprotected is the reason of the decision for the route without a policy.
+	ReasonUnprotected Reason = "unprotected"
+)
+
+// DecisionFromContext returns the decision that rejected the request.
+// The decision is available in the context passed to the ErrorResponseFunc, the ErrorHandler receives it as the argument.
+func DecisionFromContext[T RoleID](ctx context.Context) (Decision[T], bool) {
+	d, ok := ctx.Value(decisionContextKey{}).(Decision[T])
+	return d, ok
+}
+
+// decisionContextKey is the context key of the Decision.
+type decisionContextKey struct{}
+
+// newValidatorAuthorizer returns a new Authorizer based on the roleValidator.
+// The subject is allowed, if the result of the validator equals to expected,
+// otherwise the subject is rejected with the reason.
+func newValidatorAuthorizer[T RoleID](validator roleValidator, expected bool, reason Reason, required []Role[T]) Authorizer[T] {
+	a := &validatorAuthorizer[T]{
+		validator: validator,
+		expected:  expected,
+		reason:    reason,
+		required:  required,
+	}
+	return a
+}
+
+// validatorAuthorizer is an Authorizer based on the roleValidator.
+type validatorAuthorizer[T RoleID] struct {
+	validator roleValidator
+	expected  bool
+	reason    Reason
+	required  []Role[T]
+}
+
+// Authorize checks if the roles match the validator.
+func (a *validatorAuthorizer[T]) Authorize(_ *http.Request, roles []Role[T], route RouteInfo) Decision[T] {
+	d := Decision[T]{
+		Allowed:  a.match(roles) == a.expected,
+		Reason:   ReasonGranted,
+		Roles:    roles,
+		Required: a.required,
+		Route:    route,
+	}
+	if !d.Allowed {
+		d.Reason = a.reason
+	}
+	return d
+}
+
+// match checks if the roles match the validator.
+// The uint64, the Mask and the string validators are checked without boxing the role IDs,
+// the other validators receive the role IDs as the MATCH argument.
+func (a *validatorAuthorizer[T]) match(roles []Role[T]) bool {
+	switch v := a.validator.(type) {
+	case *intValidator:
+		for _, role := range roles {
+			if id, ok := interface{}(role.ID()).(uint64); ok && uint64(*v)&id == id {
+				return true
+			}
+		}
+		return false
+	case *maskValidator:
+		for _, role := range roles {
+			if id, ok := interface{}(role.ID()).(Mask); ok && Mask(*v).Contains(id) {
+				return true
+			}
+		}
+		return false
+	case *strValidator:
+		for _, role := range roles {
+			if id, ok := interface{}(role.ID()).(string); ok && v.IN(id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ids := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		ids = append(ids, role.ID())
+	}
+	return a.validator.MATCH(ids)
+}
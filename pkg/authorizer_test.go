@@ -0,0 +1,169 @@
+package rbacinjector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpRouter_HandleFuncWithAuthorizer(t *testing.T) {
+	var routes []RouteInfo
+	authorizer := AuthorizerFunc[string](func(r *http.Request, roles []Role[string], route RouteInfo) Decision[string] {
+		routes = append(routes, route)
+		d := Decision[string]{Allowed: r.Header.Get("X-Ticket") != "", Reason: ReasonGranted, Roles: roles, Route: route}
+		if !d.Allowed {
+			d.Reason = "missing ticket"
+		}
+		return d
+	})
+
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetForbiddenResponseFunc(func(w http.ResponseWriter, ctx context.Context) {
+		d, ok := DecisionFromContext[string](ctx)
+		if !ok {
+			t.Errorf("expected decision in context")
+		}
+		w.WriteHeader(d.Status())
+		_, _ = w.Write([]byte(d.Reason))
+	})
+	route, err := router.NewRoute("tickets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncWithAuthorizer("GET /{id}", httpStatusNoContent, authorizer); err != nil {
+		t.Fatal(err)
+	}
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/tickets/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, sRoleCustomer))
+	req.Header.Set("X-Ticket", "1")
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/tickets/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, sRoleCustomer))
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", res.Code)
+	} else if res.Body.String() != "missing ticket" {
+		t.Errorf("unexpected body %s", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/tickets/1", nil)
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	if len(routes) != 2 {
+		t.Fatalf("unexpected calls of authorizer %d", len(routes))
+	}
+	if expected := (RouteInfo{Pattern: "GET /tickets/{id}", Method: "GET", Path: "/tickets/{id}"}); routes[0] != expected {
+		t.Errorf("unexpected route %+v", routes[0])
+	}
+}
+
+func TestHttpRouter_HandleFuncWithAuthorizer_Nil(t *testing.T) {
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	route, err := router.NewRoute("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncWithAuthorizer("GET /", httpStatusNoContent, nil); !errors.Is(err, ErrNilAuthorizer) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	expectPanic := func(name string, f func()) {
+		defer func() {
+			if v := recover(); v == nil {
+				t.Errorf("%s: expected panic", name)
+			} else if err, ok := v.(error); !ok || !errors.Is(err, ErrNilAuthorizer) {
+				t.Errorf("%s: unexpected panic %v", name, v)
+			}
+		}()
+		f()
+	}
+	expectPanic("HandleFuncWithAuthorizer", func() {
+		router.HandleFuncWithAuthorizer("GET /admin", httpStatusNoContent, nil)
+	})
+	expectPanic("AuthorizeWith", func() {
+		AuthorizeWith[string](subjectOf(extractorSTR), httpStatusNoContent, errorUnauthorized, errorForbidden, nil)
+	})
+	if routes := router.Routes(); len(routes) != 0 {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}
+
+func TestAuthorizeWith(t *testing.T) {
+	authorizer := newValidatorAuthorizer[uint64](newRoleValidator([]Role[uint64]{iRoleAdmin}), true, ReasonNotInAllowSet, []Role[uint64]{iRoleAdmin})
+	f := AuthorizeWith[uint64](subjectExtractorINT, httpStatusNoContent, errorUnauthorized, func(w http.ResponseWriter, ctx context.Context) {
+		d, _ := DecisionFromContext[uint64](ctx)
+		if d.Reason != ReasonNotInAllowSet || len(d.Required) != 1 || len(d.Roles) != 1 {
+			t.Errorf("unexpected decision %+v", d)
+		}
+		errorForbidden(w, ctx)
+	}, authorizer)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, []Role[uint64]{iRoleCustomer, nil}))
+	f(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, []Role[uint64]{nil}))
+	f(res, req)
+	if res.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+}
+
+func TestDecision_Status(t *testing.T) {
+	cases := []struct {
+		decision Decision[string]
+		expected int
+	}{
+		{Decision[string]{Allowed: true, Reason: ReasonGranted}, http.StatusOK},
+		{Decision[string]{Reason: ReasonNoRole}, http.StatusUnauthorized},
+		{Decision[string]{Reason: ReasonInDenySet}, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if s := c.decision.Status(); s != c.expected {
+			t.Errorf("unexpected status %d for %s", s, c.decision.Reason)
+		}
+	}
+}
+
+func TestValidatorAuthorizer_NoAllocs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	uint64Authorizer := newValidatorAuthorizer[uint64](newRoleValidator([]Role[uint64]{iRoleCustomer, iRoleAdmin}), true, ReasonNotInAllowSet, nil)
+	uint64Roles := []Role[uint64]{iRoleRoot}
+	maskAuthorizer := newValidatorAuthorizer[Mask](newRoleValidator([]Role[Mask]{mRoleCustomer, mRoleAdmin}), true, ReasonNotInAllowSet, nil)
+	maskRoles := []Role[Mask]{mRoleAdmin}
+
+	if n := testing.AllocsPerRun(100, func() { uint64Authorizer.Authorize(req, uint64Roles, RouteInfo{}) }); n != 0 {
+		t.Errorf("unexpected allocations %v", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { maskAuthorizer.Authorize(req, maskRoles, RouteInfo{}) }); n != 0 {
+		t.Errorf("unexpected allocations %v", n)
+	}
+	if uint64Authorizer.Authorize(req, uint64Roles, RouteInfo{}).Allowed || !maskAuthorizer.Authorize(req, maskRoles, RouteInfo{}).Allowed {
+		t.Errorf("unexpected decisions")
+	}
+}
@@ -0,0 +1,82 @@
+package rbacinjector
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// routeBinding is the protection of the handler declared outside the code, e.g. in a spec.
+type routeBinding[T RoleID] struct {
+	// pattern is the pattern the handler is registered for.
+	pattern string
+	// handler is the handler bound to the route.
+	handler http.HandlerFunc
+	// mode is the protection mode of the route.
+	mode Mode
+	// roles are the roles of the allow or the deny set.
+	roles []Role[T]
+	// permissions are the permissions required by the route.
+	permissions []Permission
+	// line is the line of the declaration, it is 0 if the declaration has no lines.
+	line int
+}
+
+// bind validates all bindings and only then registers them, so a broken declaration registers nothing.
+func (r *HttpRouter[T]) bind(bindings []routeBinding[T]) error {
+	check := http.NewServeMux()
+	for pattern := range r.routes() {
+		if pattern != "" {
+			check.Handle(pattern, http.NotFoundHandler())
+		}
+	}
+
+	for _, b := range bindings {
+		if b.mode == ModeUnprotected && r.strict {
+			return fmt.Errorf("%w: %s", ErrUnprotectedRoute, b.pattern)
+		}
+		if err := checkPattern(check, b.pattern); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range bindings {
+		authorizer, record := r.protection(b)
+		r.register(b.pattern, b.handler, authorizer, record)
+	}
+	return nil
+}
+
+// protection returns the Authorizer and the record of the binding, the nil Authorizer leaves the route unprotected.
+func (r *HttpRouter[T]) protection(b routeBinding[T]) (Authorizer[T], RouteRecord[T]) {
+	record := RouteRecord[T]{Mode: b.mode}
+	switch b.mode {
+	case ModeAllow, ModeAllowAll, ModeDeny, ModeDenyAll:
+		record.Roles = b.roles
+		return r.rolesAuthorizer(b.mode, b.roles), record
+	case ModePermission:
+		record.Permissions = b.permissions
+		return r.permissionAuthorizer(b.permissions), record
+	case ModePublic:
+		return nil, record
+	}
+	record.Mode = ModeUnprotected
+	return nil, record
+}
+
+// checkPattern registers the pattern in the mux and turns the panic of the invalid
+// or the conflicting pattern into an error.
+func checkPattern(mux *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("invalid pattern %q: %v", pattern, v)
+		}
+	}()
+	mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// routeKey returns the method and the path of the pattern, that identify the route regardless of the spaces.
+func routeKey(pattern string) string {
+	info := newRouteInfo(pattern)
+	return info.Method + " " + info.Path
+}
@@ -0,0 +1,216 @@
+package rbacinjector
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// LoadCasbinPolicy registers the handlers for the Casbin policy in the CSV format.
+// The "p, role, /path, METHOD" lines are applied as HandleFuncAllowFor, the roles of the lines
+// with the same path and method are joined into one allow set. The method "*" matches any method,
+// the ":name" and the trailing "*" segments of the path are converted to the "{name}" and the "{path...}" wildcards.
+// The optional fourth field of the p line is the effect, only "allow" is supported.
+// The "g, role, parent" lines are applied to the role hierarchy as role inherits parent,
+// they are applied before the p lines regardless of their position. The lines are added to the hierarchy
+// set by SetRoleHierarchy in place, so it stays shared with the caller and the registered routes.
+// The handlers are bound by the converted pattern, e.g. "GET /accounts/{id}".
+// It returns PolicyFileError with the line for the malformed lines, the unknown roles and handlers,
+// nothing is registered and the role hierarchy is not changed in case of an error.
+func (r *HttpRouter[T]) LoadCasbinPolicy(rd io.Reader, handlers map[string]http.HandlerFunc) error {
+	c := csv.NewReader(rd)
+	c.FieldsPerRecord = -1
+	c.TrimLeadingSpace = true
+	c.Comment = '#'
+
+	hierarchy := r.hierarchy.clone()
+	var edges [][2]Role[T]
+	var bindings []*routeBinding[T]
+	lines := make(map[string]*routeBinding[T])
+	for {
+		record, err := c.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return &PolicyFileError{Line: parseErr.Line, Err: parseErr.Err}
+			}
+			return err
+		}
+		line, _ := c.FieldPos(0)
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+
+		switch {
+		case len(record) == 1 && record[0] == "":
+			continue
+		case record[0] == "p" && (len(record) == 4 || len(record) == 5):
+			if len(record) == 5 && record[4] != "allow" {
+				return &PolicyFileError{Line: line, Err: fmt.Errorf("unsupported effect %q", record[4])}
+			}
+			role, err := r.registry.Parse(record[1])
+			if err != nil {
+				return &PolicyFileError{Line: line, Err: err}
+			}
+			pattern := casbinPattern(record[2], record[3])
+			b, ok := lines[pattern]
+			if !ok {
+				if err = checkPattern(http.NewServeMux(), pattern); err != nil {
+					return &PolicyFileError{Line: line, Err: err}
+				}
+				handler := handlers[pattern]
+				if handler == nil {
+					return &PolicyFileError{Line: line, Err: fmt.Errorf("unknown handler %q", pattern)}
+				}
+				b = &routeBinding[T]{pattern: pattern, handler: handler, mode: ModeAllow}
+				lines[pattern] = b
+				bindings = append(bindings, b)
+			}
+			b.roles = append(b.roles, role)
+		case record[0] == "g" && len(record) == 3:
+			role, err := r.registry.Parse(record[1])
+			if err != nil {
+				return &PolicyFileError{Line: line, Err: err}
+			}
+			parent, err := r.registry.Parse(record[2])
+			if err != nil {
+				return &PolicyFileError{Line: line, Err: err}
+			}
+			if err = hierarchy.Inherit(role, parent); err != nil {
+				return &PolicyFileError{Line: line, Err: err}
+			}
+			edges = append(edges, [2]Role[T]{role, parent})
+		default:
+			return &PolicyFileError{Line: line, Err: fmt.Errorf("malformed line %q", strings.Join(record, ", "))}
+		}
+	}
+
+	allow := make([]routeBinding[T], 0, len(bindings))
+	for _, b := range bindings {
+		allow = append(allow, *b)
+	}
+	if len(edges) == 0 {
+		return r.bind(allow)
+	}
+
+	// the edges are validated on the clone, so they are applied to the hierarchy of the router in place,
+	// the hierarchy set by SetRoleHierarchy stays shared with the caller and the registered routes
+	created := r.hierarchy == nil
+	if created {
+		r.hierarchy = NewRoleHierarchy[T]()
+	}
+	previous := r.hierarchy.clone()
+	for _, e := range edges {
+		_ = r.hierarchy.Inherit(e[0], e[1])
+	}
+	if err := r.bind(allow); err != nil {
+		if created {
+			r.hierarchy = nil
+		} else {
+			*r.hierarchy = *previous
+		}
+		return err
+	}
+	return nil
+}
+
+// ExportCasbinPolicy writes the policy of the router in the Casbin CSV format.
+// The routes registered by HandleFuncAllowFor are written as the p lines, one line per role,
+// with the paths in the Casbin form, e.g. "/accounts/:id" and "/files/*", the role hierarchy is written as the g lines. The routes of the other modes can not be
+// expressed by the allow rules and are written as the comments, as the HandleFuncAllowFor routes without roles,
+// that allow any subject.
+func (r *HttpRouter[T]) ExportCasbinPolicy(w io.Writer) error {
+	c := csv.NewWriter(w)
+	var comments []string
+	for _, record := range r.Routes() {
+		if record.Mode != ModeAllow || len(record.RoleNames) == 0 {
+			pattern := record.Pattern
+			if pattern == "" {
+				pattern = "*"
+			}
+			comments = append(comments, fmt.Sprintf("# %s %s is not exported", record.Mode, pattern))
+			continue
+		}
+		method := record.Method
+		if method == "" {
+			method = "*"
+		}
+		for _, name := range record.RoleNames {
+			if err := c.Write([]string{"p", name, casbinPath(record.Path), method}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.hierarchy != nil {
+		var lines [][]string
+		for id, inherits := range r.hierarchy.inherits {
+			for _, parent := range inherits {
+				lines = append(lines, []string{"g", r.registry.Format(id), r.registry.Format(parent)})
+			}
+		}
+		sort.Slice(lines, func(i, j int) bool {
+			if lines[i][1] != lines[j][1] {
+				return lines[i][1] < lines[j][1]
+			}
+			return lines[i][2] < lines[j][2]
+		})
+		if err := c.WriteAll(lines); err != nil {
+			return err
+		}
+	}
+
+	c.Flush()
+	if err := c.Error(); err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if _, err := io.WriteString(w, comment+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// casbinPattern returns the pattern for the Casbin path and method.
+func casbinPattern(path, method string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			segments[i] = "{" + s[1:] + "}"
+		case s == "*" && i == len(segments)-1:
+			segments[i] = "{path...}"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if method == "" || method == "*" {
+		return path
+	}
+	return strings.ToUpper(method) + " " + path
+}
+
+// casbinPath returns the Casbin path of the path of the pattern, it is the inverse of casbinPattern.
+// The "{name}" wildcards are converted to ":name", the trailing "{name...}" wildcard to "*",
+// and the "{$}" anchor is removed.
+func casbinPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case s == "{$}":
+			segments[i] = ""
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "...}"):
+			segments[i] = "*"
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+			segments[i] = ":" + s[1:len(s)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,195 @@
+package rbacinjector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_LoadCasbinPolicy(t *testing.T) {
+	policy := `# accounts
+p, CUSTOMER, /accounts/:id, GET
+p, ADMIN, /accounts/:id, GET
+p, ADMIN, /files/*, *, allow
+
+g, ROOT, ADMIN
+`
+	handlers := map[string]http.HandlerFunc{
+		"GET /accounts/{id}": httpStatusNoContent,
+		"/files/{path...}":   httpStatusNoContent,
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	if err = router.LoadCasbinPolicy(strings.NewReader(policy), handlers); err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncDenyFor("DELETE /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	router.HandleFuncAllowFor("GET /open", httpStatusNoContent)
+
+	cases := []struct {
+		method   string
+		path     string
+		role     Role[uint64]
+		expected int
+	}{
+		{http.MethodGet, "/accounts/1", iRoleCustomer, http.StatusNoContent},
+		{http.MethodGet, "/accounts/1", iRoleManager, http.StatusForbidden},
+		{http.MethodPost, "/files/a/b", iRoleAdmin, http.StatusNoContent},
+		{http.MethodPost, "/files/a/b", iRoleRoot, http.StatusNoContent},
+		{http.MethodPost, "/files/a/b", iRoleCustomer, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("%s %s %v: unexpected status code %d", c.method, c.path, c.role, w.Code)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = router.ExportCasbinPolicy(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "" +
+		"p,CUSTOMER,/accounts/:id,GET\n" +
+		"p,ADMIN,/accounts/:id,GET\n" +
+		"p,ADMIN,/files/*,*\n" +
+		"g,ROOT,ADMIN\n" +
+		"# deny DELETE /accounts/{id} is not exported\n" +
+		"# allow GET /open is not exported\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected policy\n%s", buf.String())
+	}
+
+	reloaded, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, reloaded)
+	if err = reloaded.LoadCasbinPolicy(&buf, handlers); err != nil {
+		t.Fatal(err)
+	}
+	if routes := reloaded.Routes(); len(routes) != 2 {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}
+
+func TestHttpRouter_ExportCasbinPolicy_RoundTrip(t *testing.T) {
+	policy := "" +
+		"p,CUSTOMER,/accounts/:id,GET\n" +
+		"p,ADMIN,/accounts/:id/invoices/:invoice,PUT\n" +
+		"p,ADMIN,/files/*,*\n" +
+		"g,ROOT,ADMIN\n"
+	handlers := map[string]http.HandlerFunc{
+		"GET /accounts/{id}":                    httpStatusNoContent,
+		"PUT /accounts/{id}/invoices/{invoice}": httpStatusNoContent,
+		"/files/{path...}":                      httpStatusNoContent,
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	if err = router.LoadCasbinPolicy(strings.NewReader(policy), handlers); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = router.ExportCasbinPolicy(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != policy {
+		t.Errorf("unexpected policy\n%s", buf.String())
+	}
+
+	if p := casbinPath("/accounts/{$}"); p != "/accounts/" {
+		t.Errorf("unexpected path %s", p)
+	}
+}
+
+func TestHttpRouter_LoadCasbinPolicy_SharedHierarchy(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	h := NewRoleHierarchy[uint64]()
+	router.SetRoleHierarchy(h)
+	router.Permissions().Grant(iRoleAdmin, permInvoiceRead)
+	router.HandleFuncRequire("GET /invoices", httpStatusNoContent, permInvoiceRead)
+
+	handlers := map[string]http.HandlerFunc{"GET /accounts/{id}": httpStatusNoContent, "GET /invoices": httpStatusNoContent}
+	err = router.LoadCasbinPolicy(strings.NewReader("g, MANAGER, CUSTOMER\np, ADMIN, /invoices, GET\n"), handlers)
+	if err == nil {
+		t.Fatal("expected error for the registered pattern")
+	}
+	if roles := h.Expand(iRoleCustomer); len(roles) != 1 {
+		t.Errorf("unexpected hierarchy after the failed load %v", roles)
+	}
+
+	if err = router.LoadCasbinPolicy(strings.NewReader("g, ROOT, ADMIN\np, ADMIN, /accounts/:id, GET\n"), handlers); err != nil {
+		t.Fatal(err)
+	}
+	if roles := h.Expand(iRoleAdmin); len(roles) != 2 {
+		t.Errorf("unexpected hierarchy %v", roles)
+	}
+	for _, path := range []string{"/invoices", "/accounts/1"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleRoot))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: unexpected status code %d", path, w.Code)
+		}
+	}
+}
+
+func TestHttpRouter_LoadCasbinPolicy_Errors(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{"GET /a": httpStatusNoContent}
+
+	cases := []struct {
+		name     string
+		policy   string
+		line     int
+		expected error
+	}{
+		{"unknown role", "p, ADMIN, /a, GET\np, NOBODY, /a, GET\n", 2, ErrUnknownRole},
+		{"unknown handler", "p, ADMIN, /b, GET\n", 1, nil},
+		{"deny effect", "p, ADMIN, /a, GET, deny\n", 1, nil},
+		{"malformed line", "p, ADMIN, /a, GET\n\nx, ADMIN\n", 3, nil},
+		{"cycle", "g, ROOT, ADMIN\ng, ADMIN, ROOT\np, ADMIN, /a, GET\n", 2, ErrRoleHierarchyCycle},
+	}
+	for _, c := range cases {
+		router, err := NewHttpRouter[uint64](extractorINT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registerStubRoles(t, router)
+
+		err = router.LoadCasbinPolicy(strings.NewReader(c.policy), handlers)
+		var fileErr *PolicyFileError
+		if !errors.As(err, &fileErr) {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if fileErr.Line != c.line {
+			t.Errorf("%s: unexpected line %d: %v", c.name, fileErr.Line, err)
+		}
+		if c.expected != nil && !errors.Is(err, c.expected) {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if routes := router.Routes(); len(routes) != 0 {
+			t.Errorf("%s: unexpected routes %v", c.name, routes)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package rbacinjector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Explanation explains the decision of the route for the roles.
+type Explanation[T RoleID] struct {
+	// Route is the record of the route matched by the method and the path.
+	Route RouteRecord[T] `json:"route"`
+	// Roles are the names of the roles the decision is made for.
+	Roles []string `json:"roles"`
+	// Allowed is true, if the roles are granted access to the route.
+	Allowed bool `json:"allowed"`
+	// Reason is the reason of the decision.
+	Reason Reason `json:"reason"`
+	// Status is the HTTP status code of the decision.
+	Status int `json:"status"`
+	// Matched are the names or the bits of the allow or the deny set, that match the roles.
+	Matched []string `json:"matched,omitempty"`
+	// Missing are the permissions required by the route, that are not granted to the roles.
+	Missing []Permission `json:"missing,omitempty"`
+	// Message is the human-readable explanation.
+	Message string `json:"message"`
+}
+
+// Explain returns the explanation of the decision of the route matched by the method and the path for the roles.
+// The Matched are the roles of the allow or the deny set, that caused the verdict of the allow and the deny modes,
+// the Missing are the permissions, that caused the verdict of the permission mode.
+// The custom Authorizer is evaluated with a synthetic request without headers and body.
+// It returns ErrRouteNotFound if no registered pattern matches the method and the path.
+func (r *HttpRouter[T]) Explain(method, path string, roles ...Role[T]) (Explanation[T], error) {
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return Explanation[T]{}, err
+	}
+	_, pattern := r.mux.Handler(req)
+	e, ok := r.routes()[pattern]
+	if !ok || pattern == "" {
+		return Explanation[T]{}, fmt.Errorf("%w: %s %s", ErrRouteNotFound, method, path)
+	}
+
+	var route RouteRecord[T]
+	for _, record := range r.Routes() {
+		if record.Pattern == pattern {
+			route = record
+		}
+	}
+	roles = compactRoles(roles)
+	x := Explanation[T]{Route: route, Roles: make([]string, 0, len(roles))}
+	for _, role := range roles {
+		x.Roles = append(x.Roles, r.registry.Format(role.ID()))
+	}
+
+	switch {
+	case r.strict && !route.Protected():
+		x.Reason = ReasonNoPolicy
+	case e.authorizer == nil && route.Mode == ModePublic:
+		x.Allowed, x.Reason = true, ReasonPublic
+	case e.authorizer == nil:
+		x.Allowed, x.Reason = true, ReasonUnprotected
+	case len(roles) == 0:
+		x.Reason = ReasonNoRole
+	default:
+		d := e.authorizer.Authorize(req, roles, route.RouteInfo)
+		x.Allowed, x.Reason = d.Allowed, d.Reason
+	}
+	x.Status = Decision[T]{Allowed: x.Allowed, Reason: x.Reason}.Status()
+
+	ids := make([]interface{}, 0, len(roles))
+	roleIDs := make([]T, 0, len(roles))
+	for _, role := range roles {
+		ids = append(ids, role.ID())
+		roleIDs = append(roleIDs, role.ID())
+	}
+	switch route.Mode {
+	case ModeAllow, ModeAllowAll, ModeDeny, ModeDenyAll:
+		for _, required := range route.Roles {
+			if newMatchValidator(MatchAny, r.hierarchy, []Role[T]{required}).MATCH(ids) {
+				x.Matched = append(x.Matched, r.registry.Format(required.ID()))
+			}
+		}
+	case ModePermission:
+		for _, p := range route.Permissions {
+			if !r.permissions.has(r.hierarchy, roleIDs, []Permission{p}) {
+				x.Missing = append(x.Missing, p)
+			}
+		}
+	}
+	x.Message = x.message()
+	return x, nil
+}
+
+// message returns the human-readable explanation.
+func (x Explanation[T]) message() string {
+	subject := strings.Join(x.Roles, ", ")
+	set := strings.Join(x.Route.RoleNames, ", ")
+	matched := strings.Join(x.Matched, ", ")
+	route := x.Route.Pattern
+
+	switch x.Reason {
+	case ReasonNoRole:
+		return fmt.Sprintf("the subject has no role for %s", route)
+	case ReasonNoPolicy:
+		return fmt.Sprintf("%s has no policy in the strict mode", route)
+	case ReasonPublic:
+		return fmt.Sprintf("%s is public", route)
+	case ReasonUnprotected:
+		return fmt.Sprintf("%s is unprotected", route)
+	case ReasonNotInAllowSet:
+		if x.Route.Mode == ModeAllowAll {
+			return fmt.Sprintf("%s does not hold every role of the allow set [%s] of %s, matched [%s]", subject, set, route, matched)
+		}
+		return fmt.Sprintf("%s is not in the allow set [%s] of %s", subject, set, route)
+	case ReasonInDenySet:
+		return fmt.Sprintf("%s is in the deny set [%s] of %s by [%s]", subject, set, route, matched)
+	case ReasonMissingPermission:
+		return fmt.Sprintf("%s is not granted %s required by %s", subject, joinPermissions(x.Missing), route)
+	case ReasonPolicyNotSatisfied:
+		return fmt.Sprintf("%s does not satisfy the policy %s of %s", subject, x.Route.Policy, route)
+	case ReasonGranted:
+		switch x.Route.Mode {
+		case ModeAllow, ModeAllowAll:
+			return fmt.Sprintf("%s is allowed by [%s] of the allow set [%s] of %s", subject, matched, set, route)
+		case ModeDeny:
+			return fmt.Sprintf("%s is not in the deny set [%s] of %s", subject, set, route)
+		case ModeDenyAll:
+			return fmt.Sprintf("%s does not hold every role of the deny set [%s] of %s", subject, set, route)
+		}
+		return fmt.Sprintf("%s is granted access to %s", subject, route)
+	}
+	return fmt.Sprintf("%s is refused by %s: %s", subject, route, x.Reason)
+}
+
+// joinPermissions returns the permissions joined by ", ".
+func joinPermissions(permissions []Permission) string {
+	s := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		s = append(s, string(p))
+	}
+	return strings.Join(s, ", ")
+}
+
+// ExplainHandler returns the handler that writes the Explanation as JSON.
+// The request is described by the "method" and the "path" query parameters,
+// the decision is explained for the roles of the caller, or for the roles of the "roles" query parameter
+// parsed by the role registry, e.g. "?method=DELETE&path=/accounts/1&roles=CUSTOMER".
+// The handler discloses the policy, so it serves only the callers that have any of the admin roles
+// or the roles that inherit them, the other callers are rejected. Without the admin roles every caller is rejected.
+func (r *HttpRouter[T]) ExplainHandler(admins ...Role[T]) http.Handler {
+	var authorizer Authorizer[T] = AuthorizerFunc[T](func(_ *http.Request, roles []Role[T], route RouteInfo) Decision[T] {
+		return Decision[T]{Reason: ReasonNotInAllowSet, Roles: roles, Route: route}
+	})
+	if len(admins) > 0 {
+		authorizer = r.rolesAuthorizer(ModeAllow, admins)
+	}
+	return processSubject[T](r.extract, r.explain, r.reject, authorizer, RouteInfo{}, nil)
+}
+
+// explain writes the Explanation of the request described by the query parameters as JSON.
+func (r *HttpRouter[T]) explain(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	method, path := query.Get("method"), query.Get("path")
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var roles []Role[T]
+	var err error
+	if s := query.Get("roles"); s != "" {
+		roles, err = r.registry.ParseAll(s)
+	} else {
+		roles, _ = r.subjectExtractor(req)
+	}
+
+	var x Explanation[T]
+	if err == nil {
+		x, err = r.Explain(method, path, roles...)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrRouteNotFound) {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(x)
+}
@@ -0,0 +1,115 @@
+package rbacinjector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_Explain(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer, iRoleAdmin)
+	router.HandleFuncDenyFor("DELETE /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	router.Permissions().Grant(iRoleManager, permInvoiceRead)
+	router.HandleFuncRequire("POST /invoices", httpStatusNoContent, permInvoiceRead, permInvoiceWrite)
+
+	cases := []struct {
+		method  string
+		path    string
+		role    Role[uint64]
+		allowed bool
+		reason  Reason
+		matched string
+		missing string
+		message string
+	}{
+		{http.MethodGet, "/accounts/1", iRoleCustomer, true, ReasonGranted, "CUSTOMER", "", "CUSTOMER is allowed by [CUSTOMER] of the allow set [CUSTOMER, ADMIN] of GET /accounts/{id}"},
+		{http.MethodGet, "/accounts/1", iRoleManager, false, ReasonNotInAllowSet, "", "", "MANAGER is not in the allow set [CUSTOMER, ADMIN] of GET /accounts/{id}"},
+		{http.MethodDelete, "/accounts/1", iRoleCustomer, false, ReasonInDenySet, "CUSTOMER", "", "CUSTOMER is in the deny set [CUSTOMER] of DELETE /accounts/{id} by [CUSTOMER]"},
+		{http.MethodPost, "/invoices", iRoleManager, false, ReasonMissingPermission, "", string(permInvoiceWrite), ""},
+		{http.MethodGet, "/accounts/1", nil, false, ReasonNoRole, "", "", "the subject has no role for GET /accounts/{id}"},
+	}
+	for _, c := range cases {
+		var roles []Role[uint64]
+		if c.role != nil {
+			roles = append(roles, c.role)
+		}
+		x, err := router.Explain(c.method, c.path, roles...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x.Allowed != c.allowed || x.Reason != c.reason || strings.Join(x.Matched, ",") != c.matched {
+			t.Errorf("%s %s: unexpected explanation %+v", c.method, c.path, x)
+		}
+		if missing := joinPermissions(x.Missing); missing != c.missing {
+			t.Errorf("%s %s: unexpected missing permissions %s", c.method, c.path, missing)
+		}
+		if c.message != "" && x.Message != c.message {
+			t.Errorf("%s %s: unexpected message %s", c.method, c.path, x.Message)
+		}
+	}
+
+	if _, err = router.Explain(http.MethodGet, "/unknown", iRoleAdmin); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHttpRouter_ExplainHandler(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.HandleFuncDenyFor("DELETE /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	if err = router.Handle("GET /admin/explain", router.ExplainHandler(iRoleAdmin)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		target   string
+		role     Role[uint64]
+		status   int
+		expected string
+	}{
+		{"/admin/explain?method=DELETE&path=/accounts/1", iRoleAdmin, http.StatusOK, `"allowed":true`},
+		{"/admin/explain?method=DELETE&path=/accounts/1&roles=CUSTOMER", iRoleAdmin, http.StatusOK, `"reason":"in deny set"`},
+		{"/admin/explain?method=DELETE&path=/unknown", iRoleAdmin, http.StatusNotFound, `"error"`},
+		{"/admin/explain?method=DELETE&path=/accounts/1&roles=NOBODY", iRoleAdmin, http.StatusBadRequest, `"error"`},
+		{"/admin/explain?method=DELETE&path=/accounts/1", iRoleCustomer, http.StatusForbidden, ``},
+		{"/admin/explain?method=DELETE&path=/accounts/1&roles=ADMIN", iRoleCustomer, http.StatusForbidden, ``},
+		{"/admin/explain?method=DELETE&path=/accounts/1", nil, http.StatusUnauthorized, ``},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.target, nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: unexpected status code %d", c.target, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), c.expected) {
+			t.Errorf("%s: unexpected body %s", c.target, w.Body.String())
+		}
+		if w.Code == http.StatusOK && !json.Valid(w.Body.Bytes()) {
+			t.Errorf("%s: invalid body %s", c.target, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/?method=DELETE&path=/accounts/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleAdmin))
+	w := httptest.NewRecorder()
+	router.ExplainHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package rbacinjector
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRoleHierarchyCycle is returned when an inheritance would create a cycle in the RoleHierarchy.
+var ErrRoleHierarchyCycle = errors.New("role hierarchy cycle")
+
+// NewRoleHierarchy returns a new empty RoleHierarchy.
+func NewRoleHierarchy[T RoleID]() *RoleHierarchy[T] {
+	h := &RoleHierarchy[T]{
+		roles:    make(map[T]Role[T]),
+		inherits: make(map[T][]T),
+	}
+	return h
+}
+
+// RoleHierarchy is a directed acyclic graph of roles.
+// A role is granted everything that is granted to the roles it inherits.
+// The RoleHierarchy should be declared before the handlers are registered.
+type RoleHierarchy[T RoleID] struct {
+	roles    map[T]Role[T]
+	inherits map[T][]T
+}
+
+// Inherit declares that the role inherits the given roles.
+// It returns ErrRoleHierarchyCycle if any of the given roles already inherits the role.
+func (h *RoleHierarchy[T]) Inherit(role Role[T], inherits ...Role[T]) error {
+	id := role.ID()
+	for _, p := range inherits {
+		if pid := p.ID(); pid == id || h.reachable(pid, id) {
+			return fmt.Errorf("%w: %v could not inherit %v", ErrRoleHierarchyCycle, id, pid)
+		}
+	}
+
+	h.roles[id] = role
+	for _, p := range inherits {
+		pid := p.ID()
+		h.roles[pid] = p
+		if !h.reachable(id, pid) {
+			h.inherits[id] = append(h.inherits[id], pid)
+		}
+	}
+	return nil
+}
+
+// Expand returns the roles together with all roles that inherit them, directly or transitively.
+// The nil RoleHierarchy returns the roles as is.
+func (h *RoleHierarchy[T]) Expand(roles ...Role[T]) []Role[T] {
+	if h == nil || len(h.roles) == 0 {
+		return roles
+	}
+
+	seen := make(map[T]struct{}, len(roles))
+	expanded := make([]Role[T], 0, len(roles))
+	for _, r := range roles {
+		if _, ok := seen[r.ID()]; !ok {
+			seen[r.ID()] = struct{}{}
+			expanded = append(expanded, r)
+		}
+	}
+	for _, r := range roles {
+		for id, inheritor := range h.roles {
+			if _, ok := seen[id]; !ok && h.reachable(id, r.ID()) {
+				seen[id] = struct{}{}
+				expanded = append(expanded, inheritor)
+			}
+		}
+	}
+	return expanded
+}
+
+// inherited returns the role IDs together with the IDs of all roles they inherit, directly or transitively.
+// The uint64 and the Mask roles inherit the roles of every role whose bits they contain.
+// The nil RoleHierarchy returns the role IDs as is.
+func (h *RoleHierarchy[T]) inherited(ids ...T) []T {
+	if h == nil || len(h.roles) == 0 {
+		return ids
+	}
+
+	visited := make(map[T]struct{}, len(ids))
+	result := make([]T, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := visited[id]; !ok {
+			visited[id] = struct{}{}
+			result = append(result, id)
+		}
+	}
+	for node := range h.inherits {
+		for _, id := range ids {
+			if _, ok := visited[node]; !ok && holds(id, node) {
+				visited[node] = struct{}{}
+				result = append(result, node)
+			}
+		}
+	}
+	for i := 0; i < len(result); i++ {
+		for _, p := range h.inherits[result[i]] {
+			if _, ok := visited[p]; !ok {
+				visited[p] = struct{}{}
+				result = append(result, p)
+			}
+		}
+	}
+	return result
+}
+
+// reachable checks if the role "to" is inherited by the role "from", directly or transitively.
+func (h *RoleHierarchy[T]) reachable(from, to T) bool {
+	visited := make(map[T]struct{})
+	stack := []T{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := visited[id]; ok {
+			continue
+		}
+		visited[id] = struct{}{}
+		for _, p := range h.inherits[id] {
+			if p == to {
+				return true
+			}
+			stack = append(stack, p)
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the RoleHierarchy, the nil RoleHierarchy returns a new empty RoleHierarchy.
+func (h *RoleHierarchy[T]) clone() *RoleHierarchy[T] {
+	c := NewRoleHierarchy[T]()
+	if h == nil {
+		return c
+	}
+	for id, role := range h.roles {
+		c.roles[id] = role
+	}
+	for id, inherits := range h.inherits {
+		c.inherits[id] = append([]T(nil), inherits...)
+	}
+	return c
+}
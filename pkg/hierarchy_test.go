@@ -0,0 +1,159 @@
+package rbacinjector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	iRoleManager = stubRoleINT(0x0000000000000004)
+	sRoleManager = stubRoleSTR("MANAGER")
+)
+
+func TestRoleHierarchy_Inherit(t *testing.T) {
+	h := NewRoleHierarchy[string]()
+	if err := h.Inherit(sRoleAdmin, sRoleManager); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Inherit(sRoleManager, sRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Inherit(sRoleAdmin, sRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Inherit(sRoleCustomer, sRoleAdmin); !errors.Is(err, ErrRoleHierarchyCycle) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := h.Inherit(sRoleCustomer, sRoleManager); !errors.Is(err, ErrRoleHierarchyCycle) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := h.Inherit(sRoleGuest, sRoleGuest); !errors.Is(err, ErrRoleHierarchyCycle) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRoleHierarchy_Expand(t *testing.T) {
+	h := NewRoleHierarchy[uint64]()
+	if err := h.Inherit(iRoleAdmin, iRoleManager); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Inherit(iRoleManager, iRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[uint64]bool{iRoleCustomer.ID(): true, iRoleManager.ID(): true, iRoleAdmin.ID(): true}
+	expanded := h.Expand(iRoleCustomer)
+	if len(expanded) != len(expected) {
+		t.Fatalf("unexpected roles %v", expanded)
+	}
+	for _, r := range expanded {
+		if !expected[r.ID()] {
+			t.Errorf("unexpected role %v", r.ID())
+		}
+	}
+
+	if expanded = h.Expand(iRoleAdmin); len(expanded) != 1 || expanded[0].ID() != iRoleAdmin.ID() {
+		t.Errorf("unexpected roles %v", expanded)
+	}
+
+	var stub *RoleHierarchy[uint64]
+	if expanded = stub.Expand(iRoleCustomer, iRoleRoot); len(expanded) != 2 {
+		t.Errorf("unexpected roles %v", expanded)
+	}
+}
+
+func TestHttpRouter_RoleHierarchyUINT64(t *testing.T) {
+	h := NewRoleHierarchy[uint64]()
+	if err := h.Inherit(iRoleAdmin, iRoleManager); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Inherit(iRoleManager, iRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetRoleHierarchy(h)
+	router.HandleFuncAllowFor("/allow", httpStatusNoContent, iRoleCustomer)
+	router.HandleFuncDenyFor("/deny", httpStatusNoContent, iRoleManager)
+
+	cases := []struct {
+		path     string
+		role     Role[uint64]
+		expected int
+	}{
+		{"/allow", iRoleCustomer, http.StatusNoContent},
+		{"/allow", iRoleManager, http.StatusNoContent},
+		{"/allow", iRoleAdmin, http.StatusNoContent},
+		{"/allow", iRoleRoot, http.StatusForbidden},
+		{"/deny", iRoleCustomer, http.StatusNoContent},
+		{"/deny", iRoleManager, http.StatusForbidden},
+		{"/deny", iRoleAdmin, http.StatusForbidden},
+		{"/deny", iRoleRoot, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		router.ServeHTTP(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %s %v", res.Code, c.path, c.role.ID())
+		}
+	}
+}
+
+func TestHttpRouter_RoleHierarchySTRING(t *testing.T) {
+	h := NewRoleHierarchy[string]()
+	if err := h.Inherit(sRoleAdmin, sRoleManager); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Inherit(sRoleManager, sRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetRoleHierarchy(h)
+	route, err := router.NewRoute("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncAllowFor("/allow", httpStatusNoContent, sRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncDenyFor("/deny", httpStatusNoContent, sRoleManager); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path     string
+		role     Role[string]
+		expected int
+	}{
+		{"/v1/allow", sRoleCustomer, http.StatusNoContent},
+		{"/v1/allow", sRoleManager, http.StatusNoContent},
+		{"/v1/allow", sRoleAdmin, http.StatusNoContent},
+		{"/v1/allow", sRoleRoot, http.StatusForbidden},
+		{"/v1/deny", sRoleCustomer, http.StatusNoContent},
+		{"/v1/deny", sRoleManager, http.StatusForbidden},
+		{"/v1/deny", sRoleAdmin, http.StatusForbidden},
+		{"/v1/deny", sRoleRoot, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		router.ServeHTTP(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %s %v", res.Code, c.path, c.role.ID())
+		}
+	}
+}
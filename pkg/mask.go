@@ -0,0 +1,175 @@
+package rbacinjector
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// MaskBits is the number of the bit-roles a Mask can hold.
+const MaskBits = 64 * maskWords
+
+// maskWords is the number of the 64-bit words of the Mask.
+const maskWords = 4
+
+// ErrInvalidMask is returned when a string could not be parsed as a Mask.
+var ErrInvalidMask = errors.New("invalid mask")
+
+// MaskOf returns a new Mask with the given bits set.
+// It panics if any bit is not less than MaskBits.
+func MaskOf(bits ...uint) Mask {
+	var m Mask
+	for _, b := range bits {
+		if b >= MaskBits {
+			panic(fmt.Sprintf("rbacinjector: mask bit %d out of range", b))
+		}
+		m[b/64] |= 1 << (b % 64)
+	}
+	return m
+}
+
+// MaskFromUint64 returns a new Mask with the lowest 64 bits set to the value.
+func MaskFromUint64(v uint64) Mask {
+	return Mask{v}
+}
+
+// ParseMask parses the hexadecimal string, with or without the "0x" prefix, as a Mask.
+func ParseMask(s string) (Mask, error) {
+	var m Mask
+	h := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "0x"), "0X")
+	if h == "" || len(h) > MaskBits/4 {
+		return m, fmt.Errorf("%w: %q", ErrInvalidMask, s)
+	}
+	for i := 0; len(h) > 0; i++ {
+		n := len(h) - 16
+		if n < 0 {
+			n = 0
+		}
+		w, err := strconv.ParseUint(h[n:], 16, 64)
+		if err != nil {
+			return Mask{}, fmt.Errorf("%w: %q", ErrInvalidMask, s)
+		}
+		m[i] = w
+		h = h[:n]
+	}
+	return m, nil
+}
+
+// Mask is a bitmask RoleID that holds up to MaskBits bit-roles.
+// The Mask is checked bitwise as the uint64 roles.
+type Mask [maskWords]uint64
+
+// Set returns a copy of the mask with the bit set.
+// It panics if the bit is not less than MaskBits.
+func (m Mask) Set(bit uint) Mask {
+	return m.Or(MaskOf(bit))
+}
+
+// Has checks if the bit is set.
+func (m Mask) Has(bit uint) bool {
+	return bit < MaskBits && m[bit/64]&(1<<(bit%64)) != 0
+}
+
+// Or returns the union of the masks.
+func (m Mask) Or(other Mask) Mask {
+	for i := range m {
+		m[i] |= other[i]
+	}
+	return m
+}
+
+// And returns the intersection of the masks.
+func (m Mask) And(other Mask) Mask {
+	for i := range m {
+		m[i] &= other[i]
+	}
+	return m
+}
+
+// AndNot returns the bits of the mask that are not set in the other mask.
+func (m Mask) AndNot(other Mask) Mask {
+	for i := range m {
+		m[i] &^= other[i]
+	}
+	return m
+}
+
+// Contains checks if every bit of the other mask is set in the mask.
+func (m Mask) Contains(other Mask) bool {
+	for i := range m {
+		if m[i]&other[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsZero checks if no bit is set.
+func (m Mask) IsZero() bool {
+	return m == Mask{}
+}
+
+// Bits returns the set bits in ascending order.
+func (m Mask) Bits() []uint {
+	var result []uint
+	for i, w := range m {
+		for w != 0 {
+			b := bits.TrailingZeros64(w)
+			result = append(result, uint(i*64+b))
+			w &= w - 1
+		}
+	}
+	return result
+}
+
+// String returns the mask as a hexadecimal string with the "0x" prefix.
+func (m Mask) String() string {
+	i := len(m) - 1
+	for i > 0 && m[i] == 0 {
+		i--
+	}
+	var sb strings.Builder
+	sb.WriteString("0x")
+	sb.WriteString(strconv.FormatUint(m[i], 16))
+	for i--; i >= 0; i-- {
+		s := strconv.FormatUint(m[i], 16)
+		sb.WriteString(strings.Repeat("0", 16-len(s)))
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
+
+// MarshalText encodes the mask as a hexadecimal string.
+func (m Mask) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes the mask from a hexadecimal string.
+func (m *Mask) UnmarshalText(text []byte) error {
+	v, err := ParseMask(string(text))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// maskValidator is a roleValidator for the Mask roles.
+type maskValidator Mask
+
+// IN checks if the Role is in the roles.
+// The Role must be a Mask.
+// The Role is checked bitwise.
+func (v *maskValidator) IN(RoleID interface{}) bool {
+	if m, ok := RoleID.(Mask); ok {
+		return Mask(*v).Contains(m)
+	}
+	return false
+}
+
+// MATCH checks if at least one of the roles is in the roles.
+func (v *maskValidator) MATCH(RoleIDs []interface{}) bool {
+	return matchAny(v, RoleIDs)
+}
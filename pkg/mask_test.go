@@ -0,0 +1,162 @@
+package rbacinjector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var (
+	mRoleRoot     = stubRoleMASK(MaskOf(255))
+	mRoleAdmin    = stubRoleMASK(MaskOf(1))
+	mRoleCustomer = stubRoleMASK(MaskOf(4, 130))
+	mRoleGuest    = stubRoleMASK(Mask{})
+)
+
+func TestMask_Bits(t *testing.T) {
+	m := MaskOf(0, 63, 64, 200).Set(255)
+	if !reflect.DeepEqual(m.Bits(), []uint{0, 63, 64, 200, 255}) {
+		t.Errorf("unexpected bits %v", m.Bits())
+	}
+	if !m.Has(200) || m.Has(201) || m.Has(MaskBits) {
+		t.Errorf("unexpected bits %v", m.Bits())
+	}
+	if !m.Contains(MaskOf(63, 255)) || m.Contains(MaskOf(63, 254)) {
+		t.Errorf("unexpected subset check for %s", m)
+	}
+	if u := MaskOf(1).Or(MaskOf(200)); !reflect.DeepEqual(u.Bits(), []uint{1, 200}) {
+		t.Errorf("unexpected union %v", u.Bits())
+	}
+	if i := m.And(MaskOf(64, 65)); !reflect.DeepEqual(i.Bits(), []uint{64}) {
+		t.Errorf("unexpected intersection %v", i.Bits())
+	}
+	if d := m.AndNot(MaskOf(0, 63, 64, 200)); !reflect.DeepEqual(d.Bits(), []uint{255}) {
+		t.Errorf("unexpected difference %v", d.Bits())
+	}
+	if !(Mask{}).IsZero() || m.IsZero() {
+		t.Errorf("unexpected zero check")
+	}
+	if MaskFromUint64(0x12) != MaskOf(1, 4) {
+		t.Errorf("unexpected mask %s", MaskFromUint64(0x12))
+	}
+}
+
+func TestMask_String(t *testing.T) {
+	cases := []struct {
+		mask     Mask
+		expected string
+	}{
+		{Mask{}, "0x0"},
+		{MaskOf(4), "0x10"},
+		{MaskOf(64), "0x10000000000000000"},
+		{MaskOf(255, 0), "0x8000000000000000000000000000000000000000000000000000000000000001"},
+	}
+	for _, c := range cases {
+		if s := c.mask.String(); s != c.expected {
+			t.Errorf("unexpected string %s", s)
+		}
+		if m, err := ParseMask(c.expected); err != nil {
+			t.Errorf("unexpected error %s", err)
+		} else if m != c.mask {
+			t.Errorf("unexpected mask %s", m)
+		}
+	}
+
+	for _, s := range []string{"", "0x", "0xZZ", "0x1" + string(make([]byte, 64))} {
+		if _, err := ParseMask(s); !errors.Is(err, ErrInvalidMask) {
+			t.Errorf("unexpected error %v for %q", err, s)
+		}
+	}
+
+	b, err := json.Marshal(map[string]Mask{"role": MaskOf(130)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]Mask
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	} else if decoded["role"] != MaskOf(130) {
+		t.Errorf("unexpected mask %s", decoded["role"])
+	}
+}
+
+func TestAllowForMASK(t *testing.T) {
+	f := AllowFor[Mask](extractorMASK, httpStatusNoContent, errorUnauthorized, errorForbidden, mRoleCustomer, mRoleAdmin)
+
+	cases := []struct {
+		role     Role[Mask]
+		expected int
+	}{
+		{mRoleAdmin, http.StatusNoContent},
+		{mRoleCustomer, http.StatusNoContent},
+		{stubRoleMASK(MaskOf(1, 4, 130)), http.StatusNoContent},
+		{stubRoleMASK(MaskOf(130)), http.StatusNoContent},
+		{mRoleRoot, http.StatusForbidden},
+		{stubRoleMASK(MaskOf(1, 255)), http.StatusForbidden},
+		{nil, http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/allow", nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		f(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %v", res.Code, c.role)
+		}
+	}
+}
+
+func TestHttpRouter_MASK(t *testing.T) {
+	router, err := NewHttpSubjectRouter[Mask](func(r *http.Request) ([]Role[Mask], bool) {
+		roles, ok := r.Context().Value(contextRoleKey).([]Role[Mask])
+		return roles, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.Permissions().Grant(mRoleCustomer, permInvoiceRead)
+	router.HandleFuncAllowForAll("/all", httpStatusNoContent, mRoleAdmin, mRoleCustomer)
+	router.HandleFuncDenyFor("/deny", httpStatusNoContent, mRoleRoot)
+	router.HandleFuncRequire("/require", httpStatusNoContent, permInvoiceRead)
+
+	cases := []struct {
+		path     string
+		roles    []Role[Mask]
+		expected int
+	}{
+		{"/all", []Role[Mask]{mRoleAdmin, mRoleCustomer}, http.StatusNoContent},
+		{"/all", []Role[Mask]{stubRoleMASK(MaskOf(1, 4, 130, 255))}, http.StatusNoContent},
+		{"/all", []Role[Mask]{mRoleAdmin}, http.StatusForbidden},
+		{"/deny", []Role[Mask]{mRoleAdmin, mRoleCustomer}, http.StatusNoContent},
+		{"/deny", []Role[Mask]{mRoleAdmin, mRoleRoot}, http.StatusForbidden},
+		{"/require", []Role[Mask]{stubRoleMASK(MaskOf(4, 130, 255))}, http.StatusNoContent},
+		{"/require", []Role[Mask]{stubRoleMASK(MaskOf(4))}, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.roles))
+		router.ServeHTTP(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %s %v", res.Code, c.path, c.roles)
+		}
+	}
+}
+
+type stubRoleMASK Mask
+
+func (r stubRoleMASK) ID() Mask {
+	return Mask(r)
+}
+
+func extractorMASK(r *http.Request) (role Role[Mask], exists bool) {
+	val := r.Context().Value(contextRoleKey)
+	role, exists = val.(Role[Mask])
+	return
+}
@@ -0,0 +1,129 @@
+package rbacinjector
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MatrixFormat is the output format of the permission matrix.
+type MatrixFormat string
+
+const (
+	// MatrixJSON writes the permission matrix as a JSON document.
+	MatrixJSON MatrixFormat = "json"
+	// MatrixCSV writes the permission matrix as a CSV table.
+	MatrixCSV MatrixFormat = "csv"
+	// MatrixMarkdown writes the permission matrix as a Markdown table.
+	MatrixMarkdown MatrixFormat = "markdown"
+)
+
+// matrixAllow and matrixDeny are the cells of the permission matrix.
+const (
+	matrixAllow = "allow"
+	matrixDeny  = "deny"
+)
+
+// ExportMatrix writes the roles × routes matrix of all registered routes, evaluated for every known role.
+// The routes are sorted by the path and the method, the roles are sorted by the name,
+// so the output is deterministic and can be committed and diffed.
+// The wrapped handler is listed with the "*" pattern.
+func (r *HttpRouter[T]) ExportMatrix(w io.Writer, format MatrixFormat) error {
+	roles := r.Roles()
+	records, access := r.access(roles)
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, r.registry.Format(role.ID()))
+	}
+	rows := make([][]string, 0, len(records))
+	for i, record := range records {
+		pattern := record.Pattern
+		if pattern == "" {
+			pattern = "*"
+		}
+		row := []string{pattern, string(record.Mode)}
+		for _, allowed := range access[i] {
+			if allowed {
+				row = append(row, matrixAllow)
+			} else {
+				row = append(row, matrixDeny)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	switch format {
+	case MatrixJSON:
+		return writeMatrixJSON(w, names, rows)
+	case MatrixCSV:
+		return writeMatrixCSV(w, names, rows)
+	case MatrixMarkdown:
+		return writeMatrixMarkdown(w, names, rows)
+	}
+	return fmt.Errorf("unsupported matrix format %q", format)
+}
+
+// writeMatrixJSON writes the matrix as a JSON document with the roles and the routes.
+func writeMatrixJSON(w io.Writer, roles []string, rows [][]string) error {
+	type route struct {
+		Pattern string            `json:"pattern"`
+		Mode    string            `json:"mode"`
+		Access  map[string]string `json:"access"`
+	}
+	doc := struct {
+		Roles  []string `json:"roles"`
+		Routes []route  `json:"routes"`
+	}{Roles: roles, Routes: make([]route, 0, len(rows))}
+	for _, row := range rows {
+		rt := route{Pattern: row[0], Mode: row[1], Access: make(map[string]string, len(roles))}
+		for i, role := range roles {
+			rt.Access[role] = row[i+2]
+		}
+		doc.Routes = append(doc.Routes, rt)
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(doc)
+}
+
+// writeMatrixCSV writes the matrix as a CSV table with the pattern, the mode and a column per role.
+func writeMatrixCSV(w io.Writer, roles []string, rows [][]string) error {
+	c := csv.NewWriter(w)
+	if err := c.Write(append([]string{"pattern", "mode"}, roles...)); err != nil {
+		return err
+	}
+	if err := c.WriteAll(rows); err != nil {
+		return err
+	}
+	return c.Error()
+}
+
+// writeMatrixMarkdown writes the matrix as a Markdown table with the pattern, the mode and a column per role.
+func writeMatrixMarkdown(w io.Writer, roles []string, rows [][]string) error {
+	line := func(cells []string) string {
+		escaped := make([]string, 0, len(cells))
+		for _, c := range cells {
+			escaped = append(escaped, strings.ReplaceAll(c, "|", "\\|"))
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+
+	var sb strings.Builder
+	header := append([]string{"Pattern", "Mode"}, roles...)
+	sb.WriteString(line(header))
+	separator := make([]string, 0, len(header))
+	for range header {
+		separator = append(separator, "---")
+	}
+	sb.WriteString(line(separator))
+	for _, row := range rows {
+		sb.WriteString(line(row))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,110 @@
+package rbacinjector
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHttpRouter_ExportMatrix(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	route, err := router.NewRoute("accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncAllowFor("GET /{id}", httpStatusNoContent, iRoleCustomer, iRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncDenyFor("DELETE /{id}", httpStatusNoContent, iRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncWithPolicy("GET /health", httpStatusNoContent, Public[uint64]())
+
+	cases := []struct {
+		format   MatrixFormat
+		expected string
+	}{
+		{MatrixCSV, "" +
+			"pattern,mode,ADMIN,CUSTOMER,GUEST,MANAGER,ROOT\n" +
+			"DELETE /accounts/{id},deny,allow,deny,deny,allow,allow\n" +
+			"GET /accounts/{id},allow,allow,allow,allow,deny,deny\n" +
+			"GET /health,public,allow,allow,allow,allow,allow\n",
+		},
+		{MatrixMarkdown, "" +
+			"| Pattern | Mode | ADMIN | CUSTOMER | GUEST | MANAGER | ROOT |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| DELETE /accounts/{id} | deny | allow | deny | deny | allow | allow |\n" +
+			"| GET /accounts/{id} | allow | allow | allow | allow | deny | deny |\n" +
+			"| GET /health | public | allow | allow | allow | allow | allow |\n",
+		},
+		{MatrixJSON, `{
+  "roles": [
+    "ADMIN",
+    "CUSTOMER",
+    "GUEST",
+    "MANAGER",
+    "ROOT"
+  ],
+  "routes": [
+    {
+      "pattern": "DELETE /accounts/{id}",
+      "mode": "deny",
+      "access": {
+        "ADMIN": "allow",
+        "CUSTOMER": "deny",
+        "GUEST": "deny",
+        "MANAGER": "allow",
+        "ROOT": "allow"
+      }
+    },
+    {
+      "pattern": "GET /accounts/{id}",
+      "mode": "allow",
+      "access": {
+        "ADMIN": "allow",
+        "CUSTOMER": "allow",
+        "GUEST": "allow",
+        "MANAGER": "deny",
+        "ROOT": "deny"
+      }
+    },
+    {
+      "pattern": "GET /health",
+      "mode": "public",
+      "access": {
+        "ADMIN": "allow",
+        "CUSTOMER": "allow",
+        "GUEST": "allow",
+        "MANAGER": "allow",
+        "ROOT": "allow"
+      }
+    }
+  ]
+}
+`},
+	}
+	for _, c := range cases {
+		var b bytes.Buffer
+		if err = router.ExportMatrix(&b, c.format); err != nil {
+			t.Fatal(err)
+		}
+		if s := b.String(); s != c.expected {
+			t.Errorf("unexpected %s matrix:\n%s", c.format, s)
+		}
+
+		var again bytes.Buffer
+		if err = router.ExportMatrix(&again, c.format); err != nil {
+			t.Fatal(err)
+		}
+		if again.String() != b.String() {
+			t.Errorf("unstable %s matrix", c.format)
+		}
+	}
+
+	if err = router.ExportMatrix(&bytes.Buffer{}, "xml"); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,177 @@
+package rbacinjector
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExtractionBuckets are the default upper bounds, in seconds, of the role extraction histogram.
+var DefaultExtractionBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1}
+
+// Outcomes of the decisions counted by the Metrics.
+const (
+	OutcomeAllowed      = "allowed"
+	OutcomeUnauthorized = "unauthorized"
+	OutcomeForbidden    = "forbidden"
+)
+
+// NewMetrics returns a new Metrics with the upper bounds of the role extraction histogram.
+// The DefaultExtractionBuckets are used, if no bucket is given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultExtractionBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	m := &Metrics{
+		decisions: make(map[decisionSeries]uint64),
+		buckets:   buckets,
+		counts:    make([]uint64, len(buckets)),
+	}
+	return m
+}
+
+// Metrics counts the decisions of the HttpRouter by the matched pattern, the method, the role and the outcome,
+// and observes the time spent in the role extractor.
+// The Metrics is an http.Handler that writes the Prometheus text exposition format.
+// It is safe for concurrent use and can be shared by several routers.
+type Metrics struct {
+	mutex     sync.Mutex
+	decisions map[decisionSeries]uint64
+	buckets   []float64
+	counts    []uint64
+	sum       float64
+	count     uint64
+}
+
+// decisionSeries are the labels of the decision counter.
+type decisionSeries struct {
+	pattern string
+	method  string
+	role    string
+	outcome string
+}
+
+// SetMetrics sets the collector of the decisions and the role extraction time.
+func (r *HttpRouter[T]) SetMetrics(m *Metrics) {
+	r.metrics = m
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(m.String()))
+}
+
+// String returns the metrics in the Prometheus text exposition format.
+func (m *Metrics) String() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	series := make([]decisionSeries, 0, len(m.decisions))
+	for s := range m.decisions {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i], series[j]
+		if a.pattern != b.pattern {
+			return a.pattern < b.pattern
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		if a.role != b.role {
+			return a.role < b.role
+		}
+		return a.outcome < b.outcome
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# HELP rbac_decisions_total Authorization decisions by the matched pattern, the method, the role and the outcome.\n")
+	sb.WriteString("# TYPE rbac_decisions_total counter\n")
+	for _, s := range series {
+		fmt.Fprintf(&sb, "rbac_decisions_total{pattern=\"%s\",method=\"%s\",role=\"%s\",outcome=\"%s\"} %d\n",
+			escapeLabel(s.pattern), escapeLabel(s.method), escapeLabel(s.role), escapeLabel(s.outcome), m.decisions[s])
+	}
+
+	sb.WriteString("# HELP rbac_role_extraction_seconds Time spent in the role extractor.\n")
+	sb.WriteString("# TYPE rbac_role_extraction_seconds histogram\n")
+	var cumulative uint64
+	for i, bound := range m.buckets {
+		cumulative += m.counts[i]
+		fmt.Fprintf(&sb, "rbac_role_extraction_seconds_bucket{le=\"%s\"} %d\n", formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(&sb, "rbac_role_extraction_seconds_bucket{le=\"+Inf\"} %d\n", m.count)
+	fmt.Fprintf(&sb, "rbac_role_extraction_seconds_sum %s\n", formatFloat(m.sum))
+	fmt.Fprintf(&sb, "rbac_role_extraction_seconds_count %d\n", m.count)
+	return sb.String()
+}
+
+// observeDecision counts the decision.
+func (m *Metrics) observeDecision(pattern, method, role string, status int) {
+	outcome := OutcomeForbidden
+	switch status {
+	case http.StatusOK:
+		outcome = OutcomeAllowed
+	case http.StatusUnauthorized:
+		outcome = OutcomeUnauthorized
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.decisions[decisionSeries{pattern: pattern, method: method, role: role, outcome: outcome}]++
+}
+
+// observeExtraction observes the time spent in the role extractor.
+func (m *Metrics) observeExtraction(d time.Duration) {
+	seconds := d.Seconds()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			m.counts[i]++
+			break
+		}
+	}
+	m.sum += seconds
+	m.count++
+}
+
+// extract returns the roles of the subject by the subject extractor and observes the time spent in it.
+func (r *HttpRouter[T]) extract(req *http.Request) ([]Role[T], bool) {
+	m := r.metrics
+	if m == nil {
+		return r.subjectExtractor(req)
+	}
+	start := time.Now()
+	roles, ok := r.subjectExtractor(req)
+	m.observeExtraction(time.Since(start))
+	return roles, ok
+}
+
+// roleLabel returns the sorted names of the roles joined by ",".
+func (r *HttpRouter[T]) roleLabel(roles []Role[T]) string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, r.registry.Format(role.ID()))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// escapeLabel escapes the label value of the Prometheus text exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats the float of the Prometheus text exposition format.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
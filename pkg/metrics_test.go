@@ -0,0 +1,73 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_SetMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.SetMetrics(metrics)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+	router.HandleFuncWithPolicy("GET /metrics", metrics.ServeHTTP, Public[uint64]())
+
+	for _, role := range []Role[uint64]{iRoleCustomer, iRoleCustomer, iRoleManager, nil} {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		if role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, role))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %s", ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE rbac_decisions_total counter\n",
+		`rbac_decisions_total{pattern="GET /accounts/{id}",method="GET",role="",outcome="unauthorized"} 1` + "\n",
+		`rbac_decisions_total{pattern="GET /accounts/{id}",method="GET",role="CUSTOMER",outcome="allowed"} 2` + "\n",
+		`rbac_decisions_total{pattern="GET /accounts/{id}",method="GET",role="MANAGER",outcome="forbidden"} 1` + "\n",
+		"# TYPE rbac_role_extraction_seconds histogram\n",
+		`rbac_role_extraction_seconds_bucket{le="+Inf"} 5` + "\n",
+		"rbac_role_extraction_seconds_count 5\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetrics_String(t *testing.T) {
+	m := NewMetrics(0.5, 0.1)
+	m.observeDecision("GET /a\"b", http.MethodGet, "A\\B", http.StatusForbidden)
+	m.observeExtraction(200_000_000)
+
+	expected := "" +
+		"# HELP rbac_decisions_total Authorization decisions by the matched pattern, the method, the role and the outcome.\n" +
+		"# TYPE rbac_decisions_total counter\n" +
+		`rbac_decisions_total{pattern="GET /a\"b",method="GET",role="A\\B",outcome="forbidden"} 1` + "\n" +
+		"# HELP rbac_role_extraction_seconds Time spent in the role extractor.\n" +
+		"# TYPE rbac_role_extraction_seconds histogram\n" +
+		`rbac_role_extraction_seconds_bucket{le="0.1"} 0` + "\n" +
+		`rbac_role_extraction_seconds_bucket{le="0.5"} 1` + "\n" +
+		`rbac_role_extraction_seconds_bucket{le="+Inf"} 1` + "\n" +
+		"rbac_role_extraction_seconds_sum 0.2\n" +
+		"rbac_role_extraction_seconds_count 1\n"
+	if s := m.String(); s != expected {
+		t.Errorf("unexpected metrics\n%s", s)
+	}
+}
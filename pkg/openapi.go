@@ -0,0 +1,326 @@
+package rbacinjector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI extensions of the operations, that carry the protection of the routes.
+const (
+	// OpenAPIRolesAllow lists the roles of the allow set.
+	OpenAPIRolesAllow = "x-roles-allow"
+	// OpenAPIRolesDeny lists the roles of the deny set.
+	OpenAPIRolesDeny = "x-roles-deny"
+	// OpenAPIRolesMatch is "all", if the subject must hold every role of the allow or the deny set.
+	OpenAPIRolesMatch = "x-roles-match"
+	// OpenAPIRolesPolicy is the textual form of the policy.
+	OpenAPIRolesPolicy = "x-roles-policy"
+	// OpenAPIPermissions lists the required permissions.
+	OpenAPIPermissions = "x-permissions"
+	// OpenAPIProtection is the protection mode of the route.
+	OpenAPIProtection = "x-protection"
+	// OpenAPIAnyMethod is the path item extension of the patterns without a method.
+	OpenAPIAnyMethod = "x-any-method"
+)
+
+// ExportOpenAPI writes the OpenAPI 3 document with the paths skeleton of all registered routes.
+// Each operation carries its protection in the x-protection, x-roles-allow, x-roles-deny, x-roles-match,
+// x-roles-policy and x-permissions extensions, the public operations have the empty security requirement.
+// The patterns without a method are exported as the x-any-method operation of the path item.
+// The wrapped handler is not exported. It returns an error, if several patterns are exported as the same operation,
+// e.g. the patterns of different hosts or "{id}" and "{id...}", nothing is written in this case.
+func (r *HttpRouter[T]) ExportOpenAPI(w io.Writer, title, version string) error {
+	paths := make(map[string]map[string]interface{})
+	operationIDs := make(map[string]struct{})
+	exported := make(map[string]string)
+
+	for _, record := range r.Routes() {
+		if record.Pattern == "" {
+			continue
+		}
+		path, params := openAPIPath(record.Path)
+		method := OpenAPIAnyMethod
+		if record.Method != "" {
+			method = strings.ToLower(record.Method)
+		}
+		key := method + " " + path
+		if other, ok := exported[key]; ok {
+			return fmt.Errorf("openapi: %s and %s are both exported as the %s operation of %s", other, record.Pattern, method, path)
+		}
+		exported[key] = record.Pattern
+
+		op := map[string]interface{}{
+			"operationId":     openAPIOperationID(record, operationIDs),
+			OpenAPIProtection: string(record.Mode),
+			"responses": map[string]interface{}{
+				"default": map[string]interface{}{"description": "response of " + record.Handler},
+			},
+		}
+		if len(params) > 0 {
+			parameters := make([]interface{}, 0, len(params))
+			for _, p := range params {
+				parameters = append(parameters, map[string]interface{}{
+					"name":     p,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+			op["parameters"] = parameters
+		}
+
+		switch record.Mode {
+		case ModeAllow, ModeAllowAll:
+			op[OpenAPIRolesAllow] = record.RoleNames
+		case ModeDeny, ModeDenyAll:
+			op[OpenAPIRolesDeny] = record.RoleNames
+		case ModePolicy:
+			op[OpenAPIRolesPolicy] = record.Policy
+		case ModePermission:
+			op[OpenAPIPermissions] = record.Permissions
+		}
+		if record.Mode == ModeAllowAll || record.Mode == ModeDenyAll {
+			op[OpenAPIRolesMatch] = "all"
+		}
+		if record.Mode == ModePublic || record.Mode == ModeUnprotected {
+			op["security"] = []interface{}{}
+		} else {
+			responses := op["responses"].(map[string]interface{})
+			responses["401"] = map[string]interface{}{"description": http.StatusText(http.StatusUnauthorized)}
+			responses["403"] = map[string]interface{}{"description": http.StatusText(http.StatusForbidden)}
+		}
+
+		if _, ok := paths[path]; !ok {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][method] = op
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(doc)
+}
+
+// openAPIPathParam is the wildcard of the pattern.
+var openAPIPathParam = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// openAPIPath returns the OpenAPI path and the names of its parameters for the path of the pattern.
+// The host is removed, the "{name...}" wildcards are converted to "{name}" and the "{$}" anchors are removed.
+func openAPIPath(path string) (string, []string) {
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i:]
+	}
+	path = strings.ReplaceAll(path, "{$}", "")
+
+	var params []string
+	path = openAPIPathParam.ReplaceAllStringFunc(path, func(s string) string {
+		name := strings.TrimSuffix(s[1:len(s)-1], "...")
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// openAPIIdentifier is the valid name of the handler.
+var openAPIIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// openAPIAnonymous is the name of the anonymous function.
+var openAPIAnonymous = regexp.MustCompile(`^func[0-9]+$`)
+
+// openAPIOperationID returns the unique operation ID of the route.
+// The ID is the name of the handler, or the method and the path for the anonymous handlers.
+func openAPIOperationID[T RoleID](record RouteRecord[T], used map[string]struct{}) string {
+	name := record.Handler
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if !openAPIIdentifier.MatchString(name) || openAPIAnonymous.MatchString(name) {
+		name = strings.ToLower(record.Method)
+		for _, part := range strings.FieldsFunc(record.Path, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			if name != "" {
+				name += "_"
+			}
+			name += part
+		}
+		if name == "" {
+			name = "root"
+		}
+	}
+
+	id := name
+	for i := 2; ; i++ {
+		if _, ok := used[id]; !ok {
+			break
+		}
+		id = name + "_" + strconv.Itoa(i)
+	}
+	used[id] = struct{}{}
+	return id
+}
+
+// openAPIProtectionName returns the name of the protection mode of the operation for the errors.
+func openAPIProtectionName(m Mode) string {
+	if m == "" {
+		return "protected"
+	}
+	return string(m)
+}
+
+// ErrSpecDrift is returned when the operations of the spec and the handlers do not match one-to-one.
+var ErrSpecDrift = errors.New("spec and handlers drift")
+
+// openAPIMethods are the operations of the path item in the order they are registered.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace", OpenAPIAnyMethod}
+
+// LoadOpenAPI registers the handlers for the operations of the OpenAPI 3 JSON document.
+// The handlers are bound by the operationId, the roles of the x-roles-allow and the x-roles-deny
+// extensions are parsed by the role registry and applied as HandleFuncAllowFor and HandleFuncDenyFor,
+// or as HandleFuncAllowForAll and HandleFuncDenyForAll if the x-roles-match extension is "all".
+// The x-permissions extension is applied as HandleFuncRequire. The operation without the extensions is registered
+// as public, if it has the empty security requirement or the x-protection extension is "public",
+// and as unprotected, if the x-protection extension is "unprotected". Any other operation without the roles
+// or the permissions, e.g. of the custom Authorizer, is an error, so the protection is never dropped silently.
+// It returns ErrSpecDrift if any operation has no handler or any handler has no operation,
+// nothing is registered in case of an error.
+func (r *HttpRouter[T]) LoadOpenAPI(rd io.Reader, handlers map[string]http.HandlerFunc) error {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(rd).Decode(&doc); err != nil {
+		return fmt.Errorf("openapi: %w", err)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var bindings []routeBinding[T]
+	var errs []error
+	bound := make(map[string]string)
+	for _, path := range paths {
+		for _, method := range openAPIMethods {
+			raw, ok := doc.Paths[path][method]
+			if !ok {
+				continue
+			}
+			pattern := path
+			if method != OpenAPIAnyMethod {
+				pattern = strings.ToUpper(method) + " " + path
+			}
+			b, id, err := r.openAPIBinding(pattern, raw)
+			if err != nil {
+				return err
+			}
+			if other, ok := bound[id]; ok {
+				return fmt.Errorf("openapi: operationId %q of %s is used by %s", id, pattern, other)
+			}
+			bound[id] = pattern
+			if b.handler, ok = handlers[id]; !ok || b.handler == nil {
+				errs = append(errs, fmt.Errorf("%w: no handler for operation %q of %s", ErrSpecDrift, id, pattern))
+				continue
+			}
+			bindings = append(bindings, b)
+		}
+	}
+
+	ids := make([]string, 0, len(handlers))
+	for id := range handlers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, ok := bound[id]; !ok {
+			errs = append(errs, fmt.Errorf("%w: no operation for handler %q", ErrSpecDrift, id))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return r.bind(bindings)
+}
+
+// openAPIBinding decodes the operation of the pattern and returns its binding without the handler and its operationId.
+func (r *HttpRouter[T]) openAPIBinding(pattern string, raw json.RawMessage) (routeBinding[T], string, error) {
+	var op struct {
+		OperationID string          `json:"operationId"`
+		Security    *[]interface{}  `json:"security"`
+		Allow       []string        `json:"x-roles-allow"`
+		Deny        []string        `json:"x-roles-deny"`
+		Match       string          `json:"x-roles-match"`
+		Permissions []Permission    `json:"x-permissions"`
+		Policy      json.RawMessage `json:"x-roles-policy"`
+		Protection  Mode            `json:"x-protection"`
+	}
+	b := routeBinding[T]{pattern: pattern, mode: ModeUnprotected}
+	if err := json.Unmarshal(raw, &op); err != nil {
+		return b, "", fmt.Errorf("openapi: %s: %w", pattern, err)
+	}
+
+	switch {
+	case op.OperationID == "":
+		return b, "", fmt.Errorf("openapi: %s: no operationId", pattern)
+	case op.Policy != nil:
+		return b, "", fmt.Errorf("openapi: %s: %s is not supported", pattern, OpenAPIRolesPolicy)
+	case op.Match != "" && op.Match != "any" && op.Match != "all":
+		return b, "", fmt.Errorf("openapi: %s: unsupported %s %q", pattern, OpenAPIRolesMatch, op.Match)
+	}
+
+	var declared int
+	for _, ok := range []bool{op.Allow != nil, op.Deny != nil, op.Permissions != nil} {
+		if ok {
+			declared++
+		}
+	}
+	if declared > 1 {
+		return b, "", fmt.Errorf("openapi: %s: %s, %s and %s are exclusive", pattern, OpenAPIRolesAllow, OpenAPIRolesDeny, OpenAPIPermissions)
+	}
+
+	names := op.Allow
+	switch {
+	case op.Allow != nil && op.Match == "all":
+		b.mode = ModeAllowAll
+	case op.Allow != nil:
+		b.mode = ModeAllow
+	case op.Deny != nil && op.Match == "all":
+		b.mode, names = ModeDenyAll, op.Deny
+	case op.Deny != nil:
+		b.mode, names = ModeDeny, op.Deny
+	case op.Permissions != nil:
+		b.mode, b.permissions = ModePermission, op.Permissions
+	case op.Protection == ModeUnprotected:
+		b.mode = ModeUnprotected
+	case op.Protection == ModePublic || op.Protection == "" && op.Security != nil && len(*op.Security) == 0:
+		b.mode = ModePublic
+	default:
+		return b, "", fmt.Errorf("openapi: %s: no roles or permissions of the %s operation", pattern, openAPIProtectionName(op.Protection))
+	}
+
+	for _, name := range names {
+		role, err := r.registry.Parse(name)
+		if err != nil {
+			return b, "", fmt.Errorf("openapi: %s: %w", pattern, err)
+		}
+		b.roles = append(b.roles, role)
+	}
+	return b, op.OperationID, nil
+}
@@ -0,0 +1,284 @@
+package rbacinjector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_ExportOpenAPI(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	route, err := router.NewRoute("accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncAllowFor("GET /{id}", httpStatusNoContent, iRoleCustomer, iRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncDenyForAll("DELETE /{id}", httpStatusNoContent, iRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncWithPolicy("GET /health/{$}", httpStatusNoContent, Public[uint64]())
+	router.HandleFuncRequire("/files/{path...}", httpStatusNoContent, permInvoiceRead)
+
+	var buf bytes.Buffer
+	if err = router.ExportOpenAPI(&buf, "accounts", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	if err = json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("unexpected openapi version %s", doc.OpenAPI)
+	}
+	if len(doc.Paths) != 3 {
+		t.Errorf("unexpected paths %v", doc.Paths)
+	}
+
+	get := doc.Paths["/accounts/{id}"]["get"]
+	if roles, _ := json.Marshal(get[OpenAPIRolesAllow]); string(roles) != `["ADMIN","CUSTOMER"]` && string(roles) != `["CUSTOMER","ADMIN"]` {
+		t.Errorf("unexpected allow roles %s", roles)
+	}
+	if params, _ := get["parameters"].([]interface{}); len(params) != 1 {
+		t.Errorf("unexpected parameters %v", get["parameters"])
+	}
+	del := doc.Paths["/accounts/{id}"]["delete"]
+	if roles, _ := json.Marshal(del[OpenAPIRolesDeny]); string(roles) != `["CUSTOMER"]` {
+		t.Errorf("unexpected deny roles %s", roles)
+	}
+	if del[OpenAPIRolesMatch] != "all" {
+		t.Errorf("unexpected match %v", del[OpenAPIRolesMatch])
+	}
+	health := doc.Paths["/health/"]["get"]
+	if security, ok := health["security"].([]interface{}); !ok || len(security) != 0 {
+		t.Errorf("unexpected security %v", health["security"])
+	}
+	files := doc.Paths["/files/{path}"][OpenAPIAnyMethod]
+	if files[OpenAPIProtection] != string(ModePermission) {
+		t.Errorf("unexpected protection %v", files[OpenAPIProtection])
+	}
+	if perms, _ := json.Marshal(files[OpenAPIPermissions]); string(perms) != `["`+string(permInvoiceRead)+`"]` {
+		t.Errorf("unexpected permissions %s", perms)
+	}
+	if get["operationId"] == del["operationId"] {
+		t.Errorf("duplicate operation id %v", get["operationId"])
+	}
+}
+
+func TestHttpRouter_ExportOpenAPI_Collision(t *testing.T) {
+	cases := [][]string{
+		{"GET a.com/x", "GET b.com/x"},
+		{"GET /files/{id}", "GET /files/{id...}"},
+	}
+	for _, patterns := range cases {
+		router, err := NewHttpRouter[uint64](extractorINT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pattern := range patterns {
+			router.HandleFuncAllowFor(pattern, httpStatusNoContent, iRoleAdmin)
+		}
+		var buf bytes.Buffer
+		if err = router.ExportOpenAPI(&buf, "collision", "1.0.0"); err == nil {
+			t.Errorf("%v: expected error", patterns)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%v: unexpected document %s", patterns, buf.String())
+		}
+	}
+}
+
+func TestHttpRouter_LoadOpenAPI(t *testing.T) {
+	spec := `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/accounts/{id}": {
+      "get": {"operationId": "getAccount", "x-roles-allow": ["CUSTOMER", "ADMIN"]},
+      "delete": {"operationId": "deleteAccount", "x-roles-deny": ["CUSTOMER"]}
+    },
+    "/health": {
+      "get": {"operationId": "health", "security": []}
+    }
+  }
+}`
+	handlers := map[string]http.HandlerFunc{
+		"getAccount":    httpStatusNoContent,
+		"deleteAccount": httpStatusNoContent,
+		"health":        httpStatusNoContent,
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	if err = router.LoadOpenAPI(strings.NewReader(spec), handlers); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method   string
+		path     string
+		role     Role[uint64]
+		expected int
+	}{
+		{http.MethodGet, "/accounts/1", iRoleCustomer, http.StatusNoContent},
+		{http.MethodGet, "/accounts/1", iRoleManager, http.StatusForbidden},
+		{http.MethodDelete, "/accounts/1", iRoleCustomer, http.StatusForbidden},
+		{http.MethodDelete, "/accounts/1", iRoleAdmin, http.StatusNoContent},
+		{http.MethodGet, "/health", nil, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("%s %s: unexpected status code %d", c.method, c.path, w.Code)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = router.ExportOpenAPI(&buf, "accounts", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, reloaded)
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err = json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	exported := make(map[string]http.HandlerFunc)
+	for _, item := range doc.Paths {
+		for _, op := range item {
+			exported[op.OperationID] = httpStatusNoContent
+		}
+	}
+	if err = reloaded.LoadOpenAPI(&buf, exported); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := fmt.Sprint(routeModes(router)), fmt.Sprint(routeModes(reloaded)); a != b {
+		t.Errorf("unexpected round trip %s, expected %s", b, a)
+	}
+}
+
+func TestHttpRouter_LoadOpenAPI_Drift(t *testing.T) {
+	spec := `{"paths": {
+  "/accounts": {"get": {"operationId": "listAccounts", "x-roles-allow": ["ADMIN"]}},
+  "/invoices": {"get": {"operationId": "listInvoices", "x-roles-allow": ["ADMIN"]}}
+}}`
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+
+	err = router.LoadOpenAPI(strings.NewReader(spec), map[string]http.HandlerFunc{
+		"listAccounts": httpStatusNoContent,
+		"listOrders":   httpStatusNoContent,
+	})
+	if !errors.Is(err, ErrSpecDrift) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, s := range []string{"listInvoices", "listOrders"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error does not mention %s: %v", s, err)
+		}
+	}
+	if routes := router.Routes(); len(routes) != 0 {
+		t.Errorf("unexpected routes %v", routes)
+	}
+
+	err = router.LoadOpenAPI(strings.NewReader(`{"paths": {"/accounts": {"get": {"operationId": "listAccounts", "x-roles-allow": ["NOBODY"]}}}}`),
+		map[string]http.HandlerFunc{"listAccounts": httpStatusNoContent})
+	if !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHttpRouter_LoadOpenAPI_FailClosed(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.HandleFuncWithAuthorizer("GET /reports", httpStatusNoContent, AuthorizerFunc[uint64](func(r *http.Request, roles []Role[uint64], route RouteInfo) Decision[uint64] {
+		return Decision[uint64]{Allowed: true, Reason: ReasonGranted, Roles: roles, Route: route}
+	}))
+	var buf bytes.Buffer
+	if err = router.ExportOpenAPI(&buf, "reports", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	specs := []string{
+		buf.String(),
+		`{"paths": {"/reports": {"get": {"operationId": "getReports"}}}}`,
+		`{"paths": {"/reports": {"get": {"operationId": "getReports", "x-protection": "allow"}}}}`,
+	}
+	for _, spec := range specs {
+		loaded, err := NewHttpRouter[uint64](extractorINT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Paths map[string]map[string]struct {
+				OperationID string `json:"operationId"`
+			} `json:"paths"`
+		}
+		if err = json.Unmarshal([]byte(spec), &doc); err != nil {
+			t.Fatal(err)
+		}
+		handlers := map[string]http.HandlerFunc{doc.Paths["/reports"]["get"].OperationID: httpStatusNoContent}
+		if err = loaded.LoadOpenAPI(strings.NewReader(spec), handlers); err == nil {
+			t.Errorf("expected error for %s", spec)
+		}
+		if routes := loaded.Routes(); len(routes) != 0 {
+			t.Errorf("unexpected routes %v", routes)
+		}
+	}
+
+	loaded, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = loaded.LoadOpenAPI(strings.NewReader(`{"paths": {"/health": {"get": {"operationId": "health", "x-protection": "public"}}}}`),
+		map[string]http.HandlerFunc{"health": httpStatusNoContent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modes := fmt.Sprint(routeModes(loaded)); modes != "[GET /health public ]" {
+		t.Errorf("unexpected routes %s", modes)
+	}
+}
+
+// routeModes returns the patterns and the modes of the registered routes.
+func routeModes[T RoleID](router *HttpRouter[T]) []string {
+	var modes []string
+	for _, record := range router.Routes() {
+		modes = append(modes, record.Pattern+" "+string(record.Mode)+" "+strings.Join(record.RoleNames, ","))
+	}
+	return modes
+}
@@ -0,0 +1,161 @@
+package rbacinjector
+
+import (
+	"sort"
+	"sync"
+)
+
+// Permission is a named permission required by the routes, e.g. "invoice:read".
+type Permission string
+
+// NewRolePermissions returns a new empty RolePermissions.
+func NewRolePermissions[T RoleID]() *RolePermissions[T] {
+	p := &RolePermissions[T]{
+		granted: make(map[T]map[Permission]struct{}),
+	}
+	return p
+}
+
+// RolePermissions is a concurrency-safe mapping of the roles to the permissions.
+// The uint64 and the Mask roles are granted bitwise, the subject holds each role whose bits it contains.
+// The string role is granted case-sensitive.
+type RolePermissions[T RoleID] struct {
+	mutex   sync.RWMutex
+	granted map[T]map[Permission]struct{}
+}
+
+// Grant adds the permissions to the role.
+func (p *RolePermissions[T]) Grant(role Role[T], permissions ...Permission) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	id := role.ID()
+	if _, ok := p.granted[id]; !ok {
+		p.granted[id] = make(map[Permission]struct{}, len(permissions))
+	}
+	for _, permission := range permissions {
+		p.granted[id][permission] = struct{}{}
+	}
+}
+
+// Revoke removes the permissions from the role.
+// The role is removed from the mapping, if it has no permissions left.
+func (p *RolePermissions[T]) Revoke(role Role[T], permissions ...Permission) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	id := role.ID()
+	for _, permission := range permissions {
+		delete(p.granted[id], permission)
+	}
+	if len(p.granted[id]) == 0 {
+		delete(p.granted, id)
+	}
+}
+
+// Set replaces the permissions of the role.
+func (p *RolePermissions[T]) Set(role Role[T], permissions ...Permission) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	id := role.ID()
+	if len(permissions) == 0 {
+		delete(p.granted, id)
+		return
+	}
+	p.granted[id] = make(map[Permission]struct{}, len(permissions))
+	for _, permission := range permissions {
+		p.granted[id][permission] = struct{}{}
+	}
+}
+
+// Permissions returns the sorted permissions granted to the role itself.
+func (p *RolePermissions[T]) Permissions(role Role[T]) []Permission {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	permissions := make([]Permission, 0, len(p.granted[role.ID()]))
+	for permission := range p.granted[role.ID()] {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
+
+// roles returns the IDs of the roles that are granted any permission.
+func (p *RolePermissions[T]) roles() []T {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	ids := make([]T, 0, len(p.granted))
+	for id := range p.granted {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// has checks if the roles, expanded by the roles they inherit, are granted every permission.
+func (p *RolePermissions[T]) has(hierarchy *RoleHierarchy[T], roleIDs []T, permissions []Permission) bool {
+	if len(permissions) == 0 {
+		return true
+	}
+
+	held := hierarchy.inherited(roleIDs...)
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	granted := make(map[Permission]struct{})
+	for id, g := range p.granted {
+		for _, h := range held {
+			if holds(h, id) {
+				for permission := range g {
+					granted[permission] = struct{}{}
+				}
+				break
+			}
+		}
+	}
+	for _, permission := range permissions {
+		if _, ok := granted[permission]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// holds checks if the role holds the other role.
+// The uint64 and the Mask roles are checked bitwise.
+// The string role is checked case-sensitive.
+func holds[T RoleID](roleID T, other T) bool {
+	switch o := interface{}(other).(type) {
+	case uint64:
+		return (interface{}(roleID).(uint64) & o) == o
+	case Mask:
+		return interface{}(roleID).(Mask).Contains(o)
+	}
+	return roleID == other
+}
+
+// permissionValidator is a roleValidator that checks the permissions of the roles at request time.
+type permissionValidator[T RoleID] struct {
+	permissions *RolePermissions[T]
+	hierarchy   *RoleHierarchy[T]
+	required    []Permission
+}
+
+// IN checks if the Role is granted every required permission.
+func (v *permissionValidator[T]) IN(RoleID interface{}) bool {
+	return v.MATCH([]interface{}{RoleID})
+}
+
+// MATCH checks if the roles are granted every required permission together.
+func (v *permissionValidator[T]) MATCH(RoleIDs []interface{}) bool {
+	ids := make([]T, 0, len(RoleIDs))
+	for _, id := range RoleIDs {
+		if i, ok := id.(T); ok {
+			ids = append(ids, i)
+		}
+	}
+	return v.permissions.has(v.hierarchy, ids, v.required)
+}
@@ -0,0 +1,118 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const (
+	permInvoiceRead  = Permission("invoice:read")
+	permInvoiceWrite = Permission("invoice:write")
+)
+
+func TestRolePermissions_GrantRevoke(t *testing.T) {
+	p := NewRolePermissions[string]()
+	p.Grant(sRoleAdmin, permInvoiceWrite, permInvoiceRead)
+	p.Grant(sRoleCustomer, permInvoiceRead)
+
+	if l := p.Permissions(sRoleAdmin); !reflect.DeepEqual(l, []Permission{permInvoiceRead, permInvoiceWrite}) {
+		t.Errorf("unexpected permissions %v", l)
+	}
+
+	p.Revoke(sRoleAdmin, permInvoiceWrite)
+	if l := p.Permissions(sRoleAdmin); !reflect.DeepEqual(l, []Permission{permInvoiceRead}) {
+		t.Errorf("unexpected permissions %v", l)
+	}
+
+	p.Set(sRoleCustomer)
+	if l := p.Permissions(sRoleCustomer); len(l) != 0 {
+		t.Errorf("unexpected permissions %v", l)
+	}
+
+	if !p.has(nil, []string{sRoleAdmin.ID()}, []Permission{permInvoiceRead}) {
+		t.Errorf("expected permission %s", permInvoiceRead)
+	}
+	if p.has(nil, []string{sRoleAdmin.ID()}, []Permission{permInvoiceRead, permInvoiceWrite}) {
+		t.Errorf("unexpected permission %s", permInvoiceWrite)
+	}
+}
+
+func TestRolePermissions_HasUINT64(t *testing.T) {
+	h := NewRoleHierarchy[uint64]()
+	if err := h.Inherit(iRoleAdmin, iRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewRolePermissions[uint64]()
+	p.Grant(iRoleCustomer, permInvoiceRead)
+	p.Grant(iRoleAdmin, permInvoiceWrite)
+
+	if !p.has(nil, []uint64{iRoleAdmin.ID() | iRoleCustomer.ID()}, []Permission{permInvoiceRead, permInvoiceWrite}) {
+		t.Errorf("expected bitwise permissions for admin|customer")
+	}
+	if p.has(nil, []uint64{iRoleAdmin.ID()}, []Permission{permInvoiceRead}) {
+		t.Errorf("unexpected permission for admin without hierarchy")
+	}
+	if !p.has(h, []uint64{iRoleAdmin.ID()}, []Permission{permInvoiceRead, permInvoiceWrite}) {
+		t.Errorf("expected inherited permission for admin")
+	}
+	if !p.has(h, []uint64{iRoleAdmin.ID() | iRoleRoot.ID()}, []Permission{permInvoiceRead}) {
+		t.Errorf("expected inherited permission for admin|root")
+	}
+}
+
+func TestHttpRouter_HandleFuncRequire(t *testing.T) {
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.Permissions().Grant(sRoleCustomer, permInvoiceRead)
+	router.Permissions().Grant(sRoleAdmin, permInvoiceRead, permInvoiceWrite)
+
+	route, err := router.NewRoute("invoices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncRequire("GET /", httpStatusNoContent, permInvoiceRead); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncRequire("POST /", httpStatusNoContent, permInvoiceRead, permInvoiceWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(method string, role Role[string]) int {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/invoices", nil)
+		if role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, role))
+		}
+		router.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	if code := serve(http.MethodGet, sRoleCustomer); code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", code)
+	}
+	if code := serve(http.MethodPost, sRoleCustomer); code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", code)
+	}
+	if code := serve(http.MethodPost, sRoleAdmin); code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", code)
+	}
+	if code := serve(http.MethodGet, nil); code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", code)
+	}
+
+	router.Permissions().Grant(sRoleCustomer, permInvoiceWrite)
+	if code := serve(http.MethodPost, sRoleCustomer); code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", code)
+	}
+
+	router.Permissions().Revoke(sRoleAdmin, permInvoiceRead)
+	if code := serve(http.MethodGet, sRoleAdmin); code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", code)
+	}
+}
@@ -0,0 +1,236 @@
+package rbacinjector
+
+import (
+	"strings"
+)
+
+// RoleSet returns a Policy that is satisfied, if any role of the subject is contained in the roles.
+// The RoleSet without roles is satisfied by any subject, as AllowFor without roles.
+func RoleSet[T RoleID](roles ...Role[T]) Policy[T] {
+	return Policy[T]{op: policyRoleSet, roles: roles}
+}
+
+// AllOf returns a Policy that is satisfied, if every policy is satisfied.
+// The AllOf without policies is satisfied by any subject.
+func AllOf[T RoleID](policies ...Policy[T]) Policy[T] {
+	return Policy[T]{op: policyAllOf, policies: policies}
+}
+
+// AnyOf returns a Policy that is satisfied, if at least one policy is satisfied.
+// The AnyOf without policies is not satisfied by any subject.
+func AnyOf[T RoleID](policies ...Policy[T]) Policy[T] {
+	return Policy[T]{op: policyAnyOf, policies: policies}
+}
+
+// Not returns a Policy that is satisfied, if the policy is not satisfied.
+func Not[T RoleID](policy Policy[T]) Policy[T] {
+	return Policy[T]{op: policyNot, policies: []Policy[T]{policy}}
+}
+
+// Public returns a Policy that marks the route as public.
+// The handler of the public route is called for any HTTP request, even without the role.
+// The Public is only meaningful as the policy of the route, nested in other policies it is satisfied by any subject.
+func Public[T RoleID]() Policy[T] {
+	return Policy[T]{op: policyPublic}
+}
+
+// Policy is a composable expression over the role sets, that protects the route.
+// The Policy is built by RoleSet, AllOf, AnyOf, Not and Public.
+// The Policy is compiled into a validator once, when the handler is registered.
+type Policy[T RoleID] struct {
+	op       policyOp
+	roles    []Role[T]
+	policies []Policy[T]
+}
+
+// compile returns a new roleValidator that evaluates the policy.
+// The role sets are expanded by the hierarchy, the hierarchy can be nil.
+// The nested AllOf and AnyOf are flattened, the string role sets of AnyOf are merged into one set,
+// and the double negations are removed. The uint64 and the Mask role sets are not merged,
+// since the union of the sets grants the subject holding the bits of several sets.
+func (p Policy[T]) compile(hierarchy *RoleHierarchy[T]) roleValidator {
+	switch p.op {
+	case policyAllOf:
+		validators := make(andValidator, 0, len(p.policies))
+		for _, c := range p.flatten(policyAllOf) {
+			validators = append(validators, c.compile(hierarchy))
+		}
+		if len(validators) == 1 {
+			return validators[0]
+		}
+		return validators
+	case policyAnyOf:
+		var roles []Role[T]
+		var validators orValidator
+		var zero T
+		_, mergeable := interface{}(zero).(string)
+		merged := false
+		for _, c := range p.flatten(policyAnyOf) {
+			if mergeable && c.op == policyRoleSet && len(c.roles) > 0 {
+				roles = append(roles, c.roles...)
+				merged = true
+			} else {
+				validators = append(validators, c.compile(hierarchy))
+			}
+		}
+		if merged {
+			validators = append(orValidator{newRoleValidator(hierarchy.Expand(roles...))}, validators...)
+		}
+		if len(validators) == 1 {
+			return validators[0]
+		}
+		return validators
+	case policyPublic:
+		c := stubValidator(true)
+		return &c
+	case policyNot:
+		if c := p.policies[0]; c.op == policyNot {
+			return c.policies[0].compile(hierarchy)
+		}
+		return notValidator{p.policies[0].compile(hierarchy)}
+	default:
+		return newRoleValidator(hierarchy.Expand(p.roles...))
+	}
+}
+
+// String returns the textual form of the policy, e.g. "anyOf(roles(ADMIN), not(roles(CONTRACTOR)))".
+func (p Policy[T]) String() string {
+	return p.format(func(id T) string { return formatRoleID(id) })
+}
+
+// format returns the textual form of the policy with the role IDs formatted by the function.
+func (p Policy[T]) format(f func(id T) string) string {
+	var name string
+	var args []string
+	switch p.op {
+	case policyPublic:
+		return "public"
+	case policyAllOf:
+		name = "allOf"
+	case policyAnyOf:
+		name = "anyOf"
+	case policyNot:
+		name = "not"
+	default:
+		name = "roles"
+		for _, r := range p.roles {
+			args = append(args, f(r.ID()))
+		}
+	}
+	for _, c := range p.policies {
+		args = append(args, c.format(f))
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// roleSet returns the roles of all role sets of the policy.
+func (p Policy[T]) roleSet() []Role[T] {
+	roles := append([]Role[T]{}, p.roles...)
+	for _, c := range p.policies {
+		roles = append(roles, c.roleSet()...)
+	}
+	return roles
+}
+
+// flatten returns the nested policies of the same operation as a single list.
+func (p Policy[T]) flatten(op policyOp) []Policy[T] {
+	policies := make([]Policy[T], 0, len(p.policies))
+	for _, c := range p.policies {
+		if c.op == op {
+			policies = append(policies, c.flatten(op)...)
+		} else {
+			policies = append(policies, c)
+		}
+	}
+	return policies
+}
+
+// policyOp is an operation of the Policy.
+type policyOp int
+
+const (
+	policyRoleSet policyOp = iota
+	policyAllOf
+	policyAnyOf
+	policyNot
+	policyPublic
+)
+
+// andValidator is a roleValidator that requires every validator to match.
+type andValidator []roleValidator
+
+// IN checks if the Role matches every validator.
+func (a andValidator) IN(RoleID interface{}) bool {
+	return a.MATCH([]interface{}{RoleID})
+}
+
+// MATCH checks if the roles match every validator.
+func (a andValidator) MATCH(RoleIDs []interface{}) bool {
+	for _, v := range a {
+		if !v.MATCH(RoleIDs) {
+			return false
+		}
+	}
+	return true
+}
+
+// orValidator is a roleValidator that requires at least one validator to match.
+type orValidator []roleValidator
+
+// IN checks if the Role matches at least one validator.
+func (o orValidator) IN(RoleID interface{}) bool {
+	return o.MATCH([]interface{}{RoleID})
+}
+
+// MATCH checks if the roles match at least one validator.
+func (o orValidator) MATCH(RoleIDs []interface{}) bool {
+	for _, v := range o {
+		if v.MATCH(RoleIDs) {
+			return true
+		}
+	}
+	return false
+}
+
+// notValidator is a roleValidator that inverts the validator.
+// The uint64 and the Mask roles are split into the single bits, so the subject is excluded,
+// if any bit it holds matches the validator.
+type notValidator struct {
+	roleValidator
+}
+
+// IN checks if the Role does not match the validator.
+func (n notValidator) IN(RoleID interface{}) bool {
+	return n.MATCH([]interface{}{RoleID})
+}
+
+// MATCH checks if the roles do not match the validator.
+func (n notValidator) MATCH(RoleIDs []interface{}) bool {
+	return !n.roleValidator.MATCH(splitBits(RoleIDs))
+}
+
+// splitBits returns the roles with the uint64 and the Mask roles split into the single bits.
+func splitBits(RoleIDs []interface{}) []interface{} {
+	bits := make([]interface{}, 0, len(RoleIDs))
+	for _, id := range RoleIDs {
+		switch v := id.(type) {
+		case uint64:
+			if v == 0 {
+				bits = append(bits, v)
+			}
+			for ; v != 0; v &= v - 1 {
+				bits = append(bits, v&-v)
+			}
+		case Mask:
+			if v.IsZero() {
+				bits = append(bits, v)
+			}
+			for _, b := range v.Bits() {
+				bits = append(bits, MaskOf(b))
+			}
+		default:
+			bits = append(bits, id)
+		}
+	}
+	return bits
+}
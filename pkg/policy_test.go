@@ -0,0 +1,167 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	sRoleSupport    = stubRoleSTR("SUPPORT")
+	sRoleContractor = stubRoleSTR("CONTRACTOR")
+)
+
+func TestPolicy_CompileSTRING(t *testing.T) {
+	p := AnyOf[string](
+		RoleSet[string](sRoleAdmin),
+		AllOf[string](RoleSet[string](sRoleSupport), Not[string](RoleSet[string](sRoleContractor))),
+	)
+	v := p.compile(nil)
+
+	cases := []struct {
+		roles    []interface{}
+		expected bool
+	}{
+		{[]interface{}{sRoleAdmin.ID()}, true},
+		{[]interface{}{sRoleAdmin.ID(), sRoleContractor.ID()}, true},
+		{[]interface{}{sRoleSupport.ID()}, true},
+		{[]interface{}{sRoleSupport.ID(), sRoleContractor.ID()}, false},
+		{[]interface{}{sRoleCustomer.ID()}, false},
+	}
+	for _, c := range cases {
+		if v.MATCH(c.roles) != c.expected {
+			t.Errorf("unexpected result for %v", c.roles)
+		}
+	}
+}
+
+func TestPolicy_CompileUINT64(t *testing.T) {
+	p := AllOf[uint64](
+		Not[uint64](Not[uint64](RoleSet[uint64](iRoleAdmin, iRoleCustomer))),
+		AllOf[uint64](Not[uint64](RoleSet[uint64](iRoleRoot))),
+	)
+	v := p.compile(nil)
+	if _, ok := v.(andValidator); !ok {
+		t.Fatalf("unexpected validator %T", v)
+	} else if l := len(v.(andValidator)); l != 2 {
+		t.Fatalf("unexpected validators %d", l)
+	}
+
+	if !v.MATCH([]interface{}{iRoleAdmin.ID()}) {
+		t.Errorf("expected match for admin")
+	}
+	if v.MATCH([]interface{}{iRoleAdmin.ID(), iRoleRoot.ID()}) {
+		t.Errorf("unexpected match for admin and root")
+	}
+	if v.MATCH([]interface{}{iRoleManager.ID()}) {
+		t.Errorf("unexpected match for manager")
+	}
+}
+
+func TestPolicy_CompileCombinedUINT64(t *testing.T) {
+	combined := iRoleAdmin.ID() | iRoleCustomer.ID()
+
+	not := Not[uint64](RoleSet[uint64](iRoleCustomer)).compile(nil)
+	if not.MATCH([]interface{}{combined}) {
+		t.Errorf("unexpected match for admin|customer")
+	}
+	if !not.MATCH([]interface{}{iRoleAdmin.ID()}) {
+		t.Errorf("expected match for admin")
+	}
+
+	merged := AnyOf[uint64](RoleSet[uint64](iRoleAdmin), RoleSet[uint64](iRoleCustomer)).compile(nil)
+	nested := AnyOf[uint64](RoleSet[uint64](iRoleAdmin), Not[uint64](Not[uint64](RoleSet[uint64](iRoleCustomer)))).compile(nil)
+	for _, ids := range [][]interface{}{{combined}, {iRoleAdmin.ID()}, {iRoleCustomer.ID()}, {iRoleManager.ID()}} {
+		if merged.MATCH(ids) != nested.MATCH(ids) {
+			t.Errorf("%v: unexpected difference of the equivalent policies", ids)
+		}
+	}
+	if merged.MATCH([]interface{}{combined}) {
+		t.Errorf("unexpected match for admin|customer")
+	}
+}
+
+func TestPolicy_CompileCombinedMASK(t *testing.T) {
+	combined := mRoleAdmin.ID().Or(MaskOf(200))
+
+	not := Not[Mask](RoleSet[Mask](mRoleAdmin)).compile(nil)
+	if not.MATCH([]interface{}{combined}) {
+		t.Errorf("unexpected match for the combined mask")
+	}
+	if !not.MATCH([]interface{}{MaskOf(200)}) {
+		t.Errorf("expected match for bit 200")
+	}
+
+	merged := AnyOf[Mask](RoleSet[Mask](mRoleAdmin), RoleSet[Mask](stubRoleMASK(MaskOf(200)))).compile(nil)
+	if merged.MATCH([]interface{}{combined}) {
+		t.Errorf("unexpected match for the combined mask")
+	}
+	if !merged.MATCH([]interface{}{MaskOf(200)}) {
+		t.Errorf("expected match for bit 200")
+	}
+}
+
+func TestPolicy_CompileMerge(t *testing.T) {
+	p := AnyOf[string](RoleSet[string](sRoleAdmin), AnyOf[string](RoleSet[string](sRoleSupport), RoleSet[string](sRoleCustomer)))
+	v := p.compile(nil)
+	if _, ok := v.(*strValidator); !ok {
+		t.Fatalf("unexpected validator %T", v)
+	}
+	if !v.MATCH([]interface{}{sRoleCustomer.ID()}) {
+		t.Errorf("expected match for customer")
+	}
+
+	if AnyOf[string]().compile(nil).MATCH([]interface{}{sRoleAdmin.ID()}) {
+		t.Errorf("unexpected match for empty AnyOf")
+	}
+	if !AllOf[string]().compile(nil).MATCH([]interface{}{sRoleAdmin.ID()}) {
+		t.Errorf("expected match for empty AllOf")
+	}
+}
+
+func TestHttpRouter_HandleFuncWithPolicy(t *testing.T) {
+	h := NewRoleHierarchy[string]()
+	if err := h.Inherit(sRoleRoot, sRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpSubjectRouter[string](subjectExtractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetRoleHierarchy(h)
+	route, err := router.NewRoute("tickets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := AnyOf[string](
+		RoleSet[string](sRoleAdmin),
+		AllOf[string](RoleSet[string](sRoleSupport), Not[string](RoleSet[string](sRoleContractor))),
+	)
+	if err = route.HandleFuncWithPolicy("GET /", httpStatusNoContent, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		roles    []Role[string]
+		expected int
+	}{
+		{[]Role[string]{sRoleRoot}, http.StatusNoContent},
+		{[]Role[string]{sRoleSupport}, http.StatusNoContent},
+		{[]Role[string]{sRoleSupport, sRoleContractor}, http.StatusForbidden},
+		{[]Role[string]{sRoleCustomer}, http.StatusForbidden},
+		{nil, http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/tickets", nil)
+		if c.roles != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.roles))
+		}
+		router.ServeHTTP(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %v", res.Code, c.roles)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rbacinjector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PolicyFileError is the error of the policy file, that refers to the line of the entry.
+type PolicyFileError struct {
+	// Line is the line of the policy file, starting from 1.
+	Line int
+	// Err is the cause of the error.
+	Err error
+}
+
+// Error returns the error message with the line.
+func (e *PolicyFileError) Error() string {
+	return fmt.Sprintf("policy file: line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (e *PolicyFileError) Unwrap() error {
+	return e.Err
+}
+
+// PolicyEntry is the entry of the policy file.
+type PolicyEntry struct {
+	// Pattern is the pattern the handler is registered for.
+	Pattern string `json:"pattern"`
+	// Handler is the name of the handler.
+	Handler string `json:"handler"`
+	// Mode is one of "allow", "allow-all", "deny", "deny-all" and "public".
+	Mode Mode `json:"mode"`
+	// Roles are the names of the roles, the names joined by "|", or the numbers of the uint64 and the Mask roles.
+	// The number is the value of the bits, not the index of a bit, e.g. 4 is the bit 2.
+	Roles []json.RawMessage `json:"roles,omitempty"`
+}
+
+// LoadPolicyFile registers the handlers for the entries of the JSON policy file.
+// The file is a list of entries, e.g.
+//
+//	[
+//	  {"pattern": "GET /accounts/{id}", "handler": "getAccount", "mode": "allow", "roles": ["CUSTOMER", "ADMIN"]},
+//	  {"pattern": "DELETE /accounts/{id}", "handler": "deleteAccount", "mode": "deny", "roles": ["CUSTOMER", 4]}
+//	]
+//
+// The handlers are bound by the name, the roles are parsed by the role registry.
+// It returns PolicyFileError with the line of the entry for the unknown roles, handlers and modes,
+// the malformed patterns and the duplicate entries, nothing is registered in case of an error.
+func (r *HttpRouter[T]) LoadPolicyFile(rd io.Reader, handlers map[string]http.HandlerFunc) error {
+	bindings, err := r.decodePolicyFile(rd, handlers)
+	if err != nil {
+		return err
+	}
+	return r.bind(bindings)
+}
+
+// decodePolicyFile returns the bindings of the entries of the JSON policy file.
+// The handlers are not bound, if the handlers are nil.
+func (r *HttpRouter[T]) decodePolicyFile(rd io.Reader, handlers map[string]http.HandlerFunc) ([]routeBinding[T], error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []routeBinding[T]
+	lines := make(map[string]int)
+	declared := http.NewServeMux()
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err = expectDelim(d, '['); err != nil {
+		return nil, policyFileError(data, lineOf(data, 0), err)
+	}
+	for d.More() {
+		line := lineOf(data, d.InputOffset())
+
+		var entry PolicyEntry
+		if err = d.Decode(&entry); err != nil {
+			return nil, policyFileError(data, line, err)
+		}
+		b, err := r.policyBinding(entry, handlers)
+		if err != nil {
+			return nil, &PolicyFileError{Line: line, Err: err}
+		}
+		b.line = line
+		key := routeKey(b.pattern)
+		if first, ok := lines[key]; ok {
+			return nil, &PolicyFileError{Line: line, Err: fmt.Errorf("duplicate pattern %q, first declared at line %d", entry.Pattern, first)}
+		}
+		if err = checkPattern(declared, b.pattern); err != nil {
+			return nil, &PolicyFileError{Line: line, Err: err}
+		}
+		lines[key] = line
+		bindings = append(bindings, b)
+	}
+	if err = expectDelim(d, ']'); err != nil {
+		return nil, policyFileError(data, lineOf(data, d.InputOffset()), err)
+	}
+	return bindings, nil
+}
+
+// policyBinding returns the binding of the entry of the policy file.
+func (r *HttpRouter[T]) policyBinding(entry PolicyEntry, handlers map[string]http.HandlerFunc) (routeBinding[T], error) {
+	b := routeBinding[T]{pattern: entry.Pattern, mode: entry.Mode}
+	switch entry.Mode {
+	case ModeAllow, ModeAllowAll, ModeDeny, ModeDenyAll, ModePublic:
+	default:
+		return b, fmt.Errorf("unsupported mode %q", entry.Mode)
+	}
+	if err := checkPattern(http.NewServeMux(), entry.Pattern); err != nil {
+		return b, err
+	}
+	if b.handler = handlers[entry.Handler]; handlers != nil && b.handler == nil {
+		return b, fmt.Errorf("unknown handler %q", entry.Handler)
+	}
+	if entry.Mode == ModePublic && len(entry.Roles) > 0 {
+		return b, errors.New("public entry with roles")
+	}
+
+	for _, raw := range entry.Roles {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			var n json.Number
+			if err = json.Unmarshal(raw, &n); err != nil {
+				return b, fmt.Errorf("role %s is neither a name nor a bit", raw)
+			}
+			s = n.String()
+		}
+		role, err := r.registry.Parse(s)
+		if err != nil {
+			return b, err
+		}
+		b.roles = append(b.roles, role)
+	}
+	return b, nil
+}
+
+// expectDelim reads the next token and checks it is the delimiter.
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	t, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("expected %q, got %v", delim, t)
+	}
+	return nil
+}
+
+// policyFileError returns the PolicyFileError for the decoding error.
+// The syntax and the type errors refer to their own line, any other error refers to the line.
+func policyFileError(data []byte, line int, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line = bytes.Count(data[:min(syntaxErr.Offset, int64(len(data)))], []byte("\n")) + 1
+	case errors.As(err, &typeErr):
+		line = bytes.Count(data[:min(typeErr.Offset, int64(len(data)))], []byte("\n")) + 1
+	case errors.Is(err, io.EOF):
+		err = io.ErrUnexpectedEOF
+	}
+	return &PolicyFileError{Line: line, Err: err}
+}
+
+// lineOf returns the line of the first token at or after the offset, starting from 1.
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[offset]) >= 0 {
+		offset++
+	}
+	if offset == int64(len(data)) && offset > 0 {
+		offset--
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,148 @@
+package rbacinjector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_LoadPolicyFile(t *testing.T) {
+	file := `[
+  {"pattern": "GET /accounts/{id}", "handler": "getAccount", "mode": "allow", "roles": ["CUSTOMER", "ADMIN"]},
+  {"pattern": "DELETE /accounts/{id}", "handler": "deleteAccount", "mode": "deny", "roles": ["CUSTOMER", 1]},
+  {"pattern": "GET /health", "handler": "health", "mode": "public"}
+]`
+	handlers := map[string]http.HandlerFunc{
+		"getAccount":    httpStatusNoContent,
+		"deleteAccount": httpStatusNoContent,
+		"health":        httpStatusNoContent,
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	if err = router.LoadPolicyFile(strings.NewReader(file), handlers); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method   string
+		path     string
+		role     Role[uint64]
+		expected int
+	}{
+		{http.MethodGet, "/accounts/1", iRoleCustomer, http.StatusNoContent},
+		{http.MethodGet, "/accounts/1", iRoleManager, http.StatusForbidden},
+		{http.MethodDelete, "/accounts/1", iRoleCustomer, http.StatusForbidden},
+		{http.MethodDelete, "/accounts/1", iRoleAdmin, http.StatusNoContent},
+		{http.MethodGet, "/health", nil, http.StatusNoContent},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("%s %s: unexpected status code %d", c.method, c.path, w.Code)
+		}
+	}
+}
+
+func TestHttpRouter_LoadPolicyFileMASK(t *testing.T) {
+	router, err := NewHttpRouter[Mask](extractorMASK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := `[{"pattern": "GET /accounts/{id}", "handler": "getAccount", "mode": "allow", "roles": [2]}]`
+	if err = router.LoadPolicyFile(strings.NewReader(policy), map[string]http.HandlerFunc{"getAccount": httpStatusNoContent}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		role     Role[Mask]
+		expected int
+	}{
+		{mRoleAdmin, http.StatusNoContent},
+		{mRoleCustomer, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("%v: unexpected status code %d", c.role, w.Code)
+		}
+	}
+}
+
+func TestHttpRouter_LoadPolicyFile_Errors(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{"a": httpStatusNoContent, "b": httpStatusNoContent}
+
+	cases := []struct {
+		name     string
+		file     string
+		line     int
+		expected string
+	}{
+		{"unknown role", `[
+  {"pattern": "GET /a", "handler": "a", "mode": "allow", "roles": ["ADMIN"]},
+  {"pattern": "GET /b", "handler": "b", "mode": "allow", "roles": ["NOBODY"]}
+]`, 3, "unknown role"},
+		{"malformed pattern", `[
+
+  {"pattern": "GET /a/{id", "handler": "a", "mode": "allow", "roles": ["ADMIN"]}
+]`, 3, "invalid pattern"},
+		{"duplicate entry", `[
+  {"pattern": "GET /a", "handler": "a", "mode": "allow", "roles": ["ADMIN"]},
+  {"pattern": "GET  /a", "handler": "b", "mode": "deny", "roles": ["ADMIN"]}
+]`, 3, "first declared at line 2"},
+		{"conflicting entry", `[
+  {"pattern": "GET /a/{x}", "handler": "a", "mode": "allow", "roles": ["ADMIN"]},
+  {"pattern": "GET /a/{y}", "handler": "b", "mode": "allow", "roles": ["ADMIN"]}
+]`, 3, "conflicts"},
+		{"unknown handler", `[
+  {"pattern": "GET /c", "handler": "c", "mode": "allow", "roles": ["ADMIN"]}
+]`, 2, "unknown handler"},
+		{"unknown mode", `[
+  {"pattern": "GET /a", "handler": "a", "mode": "grant", "roles": ["ADMIN"]}
+]`, 2, "unsupported mode"},
+		{"unknown field", `[
+  {"pattern": "GET /a", "handler": "a", "mode": "allow", "role": ["ADMIN"]}
+]`, 2, "unknown field"},
+		{"syntax error", `[
+  {"pattern": "GET /a", "handler": "a", "mode": "allow", "roles": ["ADMIN"]},
+  {"pattern": "GET /b" "handler": "b"}
+]`, 3, "invalid character"},
+	}
+	for _, c := range cases {
+		router, err := NewHttpRouter[uint64](extractorINT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registerStubRoles(t, router)
+
+		err = router.LoadPolicyFile(strings.NewReader(c.file), handlers)
+		var fileErr *PolicyFileError
+		if !errors.As(err, &fileErr) {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if fileErr.Line != c.line {
+			t.Errorf("%s: unexpected line %d: %v", c.name, fileErr.Line, err)
+		}
+		if !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if routes := router.Routes(); len(routes) != 0 {
+			t.Errorf("%s: unexpected routes %v", c.name, routes)
+		}
+	}
+}
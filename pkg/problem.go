@@ -0,0 +1,139 @@
+package rbacinjector
+
+import (
+	"encoding/json"
+	"html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types of the error responses negotiated by the ProblemResponder.
+const (
+	MediaTypeProblemJSON = "application/problem+json"
+	MediaTypeHTML        = "text/html"
+)
+
+// RequestIDHeader is the default header of the request ID.
+const RequestIDHeader = "X-Request-Id"
+
+// DefaultProblemTypeURI is the default prefix of the type URI of the problem details.
+const DefaultProblemTypeURI = "urn:rbacinjector:problem:"
+
+// DefaultProblemTemplate is the default HTML template of the error responses, it renders the ProblemDetails.
+var DefaultProblemTemplate = template.Must(template.New("problem").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>{{.Status}} {{.Title}}</title></head>
+<body>
+<h1>{{.Status}} {{.Title}}</h1>
+<p>{{.Detail}}</p>
+<p><small>{{.Instance}}{{if .RequestID}} &middot; request {{.RequestID}}{{end}}</small></p>
+</body>
+</html>
+`))
+
+// ProblemDetails is the RFC 9457 problem details of the rejected request.
+type ProblemDetails struct {
+	// Type is the URI of the problem type, it is the type URI prefix followed by the reason of the decision.
+	Type string `json:"type"`
+	// Title is the status text of the status.
+	Title string `json:"title"`
+	// Status is the HTTP status code of the decision.
+	Status int `json:"status"`
+	// Detail is the reason of the decision.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request.
+	Instance string `json:"instance"`
+	// RequestID is the ID of the request from the request ID header, or the generated one.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewProblemResponder returns a new ProblemResponder with the default type URI, template and request ID header.
+func NewProblemResponder[T RoleID]() *ProblemResponder[T] {
+	p := &ProblemResponder[T]{
+		TypeURI:         DefaultProblemTypeURI,
+		Template:        DefaultProblemTemplate,
+		RequestIDHeader: RequestIDHeader,
+	}
+	return p
+}
+
+// ProblemResponder writes the error responses of the rejected requests negotiated on the Accept header:
+// the HTML page rendered by the Template for the browsers, the RFC 9457 problem details for the other clients.
+// It is the default error response of the HttpRouter.
+type ProblemResponder[T RoleID] struct {
+	// TypeURI is the prefix of the type URI of the problem details.
+	TypeURI string
+	// Template renders the ProblemDetails for the browsers.
+	Template *template.Template
+	// RequestIDHeader is the header of the request ID. The missing request ID is generated
+	// and set to the response header, so the client can report it.
+	RequestIDHeader string
+}
+
+// SetProblemResponder sets the responder of the rejected requests, that have no ErrorResponseFunc.
+// The nil ProblemResponder restores the bare status responses.
+func (r *HttpRouter[T]) SetProblemResponder(p *ProblemResponder[T]) {
+	r.problemResponder = p
+}
+
+// ServeError writes the error response of the request rejected by the decision, it is an ErrorHandler.
+func (p *ProblemResponder[T]) ServeError(w http.ResponseWriter, r *http.Request, d Decision[T]) {
+	status := d.Status()
+	problem := ProblemDetails{
+		Type:     p.TypeURI + strings.ReplaceAll(string(d.Reason), " ", "-"),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   string(d.Reason),
+		Instance: r.URL.Path,
+	}
+	if p.RequestIDHeader != "" {
+		problem.RequestID = r.Header.Get(p.RequestIDHeader)
+		if problem.RequestID == "" {
+			problem.RequestID = randomHex(8)
+		}
+		w.Header().Set(p.RequestIDHeader, problem.RequestID)
+	}
+
+	if p.Template != nil && negotiate(r.Header.Get("Accept")) == MediaTypeHTML {
+		var sb strings.Builder
+		if err := p.Template.Execute(&sb, problem); err == nil {
+			w.Header().Set("Content-Type", MediaTypeHTML+"; charset=utf-8")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(sb.String()))
+			return
+		}
+	}
+	w.Header().Set("Content-Type", MediaTypeProblemJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// negotiate returns the media type of the error response preferred by the Accept header.
+// The HTML is chosen only if it is preferred over the JSON, so the clients that accept anything get the problem details.
+func negotiate(accept string) string {
+	var html, json float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case MediaTypeHTML, "application/xhtml+xml":
+			html = max(html, q)
+		case MediaTypeProblemJSON, "application/json", "application/*", "*/*":
+			json = max(json, q)
+		}
+	}
+	if html > json {
+		return MediaTypeHTML
+	}
+	return MediaTypeProblemJSON
+}
@@ -0,0 +1,117 @@
+package rbacinjector
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_ProblemResponder(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin)
+
+	cases := []struct {
+		role   Role[uint64]
+		status int
+		reason Reason
+	}{
+		{iRoleCustomer, http.StatusForbidden, ReasonNotInAllowSet},
+		{nil, http.StatusUnauthorized, ReasonNoRole},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(RequestIDHeader, "req-1")
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("unexpected status code %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != MediaTypeProblemJSON {
+			t.Errorf("unexpected content type %s", ct)
+		}
+		var problem ProblemDetails
+		if err = json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatal(err)
+		}
+		expected := ProblemDetails{
+			Type:      DefaultProblemTypeURI + strings.ReplaceAll(string(c.reason), " ", "-"),
+			Title:     http.StatusText(c.status),
+			Status:    c.status,
+			Detail:    string(c.reason),
+			Instance:  "/accounts/1",
+			RequestID: "req-1",
+		}
+		if problem != expected {
+			t.Errorf("unexpected problem %+v", problem)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, MediaTypeHTML) {
+		t.Errorf("unexpected content type %s", ct)
+	}
+	id := w.Header().Get(RequestIDHeader)
+	if id == "" || !strings.Contains(w.Body.String(), "401 Unauthorized") || !strings.Contains(w.Body.String(), id) {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+
+	p := NewProblemResponder[uint64]()
+	p.TypeURI = "https://example.com/problems/"
+	p.Template = template.Must(template.New("custom").Parse(`<p>{{.Title}} {{.Instance}}</p>`))
+	router.SetProblemResponder(p)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if body := w.Body.String(); body != "<p>Unauthorized /accounts/1</p>" {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	router.SetUnauthorizedResponseFunc(errorUnauthorized)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || strings.TrimSpace(w.Body.String()) != "unauthorized" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	router.SetUnauthorizedResponseFunc(nil)
+	router.SetProblemResponder(nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || w.Body.Len() != 0 {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                                  MediaTypeProblemJSON,
+		"*/*":                               MediaTypeProblemJSON,
+		"application/json":                  MediaTypeProblemJSON,
+		"application/problem+json":          MediaTypeProblemJSON,
+		"text/html":                         MediaTypeHTML,
+		"text/html;q=0.5, application/json": MediaTypeProblemJSON,
+		"application/json;q=0.1, text/html": MediaTypeHTML,
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8": MediaTypeHTML,
+	}
+	for accept, expected := range cases {
+		if actual := negotiate(accept); actual != expected {
+			t.Errorf("%q: unexpected media type %s", accept, actual)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package rbacinjector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrDuplicateRole is returned when a role ID, a bit or a name is already registered.
+	ErrDuplicateRole = errors.New("duplicate role")
+	// ErrUnknownRole is returned when a role could not be decoded.
+	ErrUnknownRole = errors.New("unknown role")
+)
+
+// NewRoleRegistry returns a new empty RoleRegistry.
+func NewRoleRegistry[T RoleID]() *RoleRegistry[T] {
+	g := &RoleRegistry[T]{
+		names: make(map[T]string),
+		ids:   make(map[string]T),
+	}
+	return g
+}
+
+// RoleRegistry is a concurrency-safe mapping of the role IDs to the human-readable names.
+// The uint64 and the Mask roles must not share bits, so the combined roles are decoded and
+// formatted as the names joined by "|", e.g. "ADMIN|CUSTOMER".
+type RoleRegistry[T RoleID] struct {
+	mutex sync.RWMutex
+	names map[T]string
+	ids   map[string]T
+}
+
+// Register adds the role ID with the name and returns the NamedRole.
+// It returns ErrDuplicateRole if the ID, any of its bits or the name is already registered.
+func (g *RoleRegistry[T]) Register(id T, name string) (NamedRole[T], error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "|,") {
+		return NamedRole[T]{}, fmt.Errorf("invalid role name %q", name)
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if n, ok := g.names[id]; ok {
+		return NamedRole[T]{}, fmt.Errorf("%w: %s is registered as %s", ErrDuplicateRole, formatRoleID(id), n)
+	}
+	if _, ok := g.ids[name]; ok {
+		return NamedRole[T]{}, fmt.Errorf("%w: name %s", ErrDuplicateRole, name)
+	}
+	for other, n := range g.names {
+		if overlaps(id, other) {
+			return NamedRole[T]{}, fmt.Errorf("%w: %s shares bits with %s", ErrDuplicateRole, formatRoleID(id), n)
+		}
+	}
+
+	g.names[id] = name
+	g.ids[name] = id
+	return NamedRole[T]{id: id, name: name}, nil
+}
+
+// Name returns the name of the role ID.
+func (g *RoleRegistry[T]) Name(id T) (string, bool) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	name, ok := g.names[id]
+	return name, ok
+}
+
+// Lookup returns the role by the name.
+func (g *RoleRegistry[T]) Lookup(name string) (NamedRole[T], bool) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	id, ok := g.ids[strings.TrimSpace(name)]
+	return NamedRole[T]{id: id, name: strings.TrimSpace(name)}, ok
+}
+
+// Roles returns all registered roles sorted by the name.
+func (g *RoleRegistry[T]) Roles() []NamedRole[T] {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	roles := make([]NamedRole[T], 0, len(g.names))
+	for id, name := range g.names {
+		roles = append(roles, NamedRole[T]{id: id, name: name})
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].name < roles[j].name })
+	return roles
+}
+
+// Format returns the human-readable name of the role ID.
+// The combined uint64 and Mask roles are formatted as the names joined by "|",
+// the bits without a name are formatted as a hexadecimal number.
+// The nil RoleRegistry formats the role ID as is.
+func (g *RoleRegistry[T]) Format(id T) string {
+	if g == nil {
+		return formatRoleID(id)
+	}
+
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	if name, ok := g.names[id]; ok {
+		return name
+	}
+	if _, ok := interface{}(id).(string); ok {
+		return formatRoleID(id)
+	}
+
+	var names []string
+	rest := interface{}(id)
+	for other, name := range g.names {
+		if !isZeroRoleID(other) && holds(id, other) {
+			names = append(names, name)
+			rest = clearBits(rest, other)
+		}
+	}
+	sort.Strings(names)
+	if !isZeroRoleID(rest) || len(names) == 0 {
+		names = append(names, formatRoleID(rest))
+	}
+	return strings.Join(names, "|")
+}
+
+// Parse decodes the role from the name, the names joined by "|" for the uint64 and the Mask roles,
+// or a number with the "0x" prefix or a decimal number for the uint64 and the Mask roles.
+// The number is the value of the bits, not the index of a bit.
+// It returns ErrUnknownRole if any name is not registered.
+// The nil RoleRegistry decodes the string roles as is and the numbers only.
+func (g *RoleRegistry[T]) Parse(s string) (Role[T], error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty", ErrUnknownRole)
+	}
+
+	var zero T
+	if _, ok := interface{}(zero).(string); ok {
+		if g == nil {
+			return NamedRole[T]{id: interface{}(s).(T), name: s}, nil
+		}
+		if r, ok := g.Lookup(s); ok {
+			return r, nil
+		}
+		if name, ok := g.Name(interface{}(s).(T)); ok {
+			return NamedRole[T]{id: interface{}(s).(T), name: name}, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRole, s)
+	}
+
+	var combined interface{} = zero
+	for _, part := range strings.Split(s, "|") {
+		part = strings.TrimSpace(part)
+		if id, ok := parseRoleNumber[T](part); ok {
+			combined = combineBits(combined, id)
+		} else if r, ok := g.lookup(part); ok {
+			combined = combineBits(combined, r.ID())
+		} else {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRole, part)
+		}
+	}
+	id := combined.(T)
+	return NamedRole[T]{id: id, name: g.Format(id)}, nil
+}
+
+// ParseAll decodes the roles separated by ",".
+func (g *RoleRegistry[T]) ParseAll(s string) ([]Role[T], error) {
+	var roles []Role[T]
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		r, err := g.Parse(part)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	return roles, nil
+}
+
+// ErrorResponseFunc returns an ErrorResponseFunc that writes the status and a plain text body
+// with the names of the rejected roles.
+func (g *RoleRegistry[T]) ErrorResponseFunc(status int) ErrorResponseFunc {
+	return func(w http.ResponseWriter, ctx context.Context) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		msg := strings.ToLower(http.StatusText(status))
+		if d, ok := DecisionFromContext[T](ctx); ok && len(d.Roles) > 0 {
+			names := make([]string, 0, len(d.Roles))
+			for _, r := range d.Roles {
+				names = append(names, g.Format(r.ID()))
+			}
+			msg += ": " + strings.Join(names, ", ")
+		}
+		_, _ = w.Write([]byte(msg))
+	}
+}
+
+// lookup returns the role by the name, the nil RoleRegistry has no roles.
+func (g *RoleRegistry[T]) lookup(name string) (NamedRole[T], bool) {
+	if g == nil {
+		return NamedRole[T]{}, false
+	}
+	return g.Lookup(name)
+}
+
+// NamedRole is a Role with the human-readable name.
+type NamedRole[T RoleID] struct {
+	id   T
+	name string
+}
+
+// ID returns the ID of the role.
+func (r NamedRole[T]) ID() T {
+	return r.id
+}
+
+// Name returns the name of the role.
+func (r NamedRole[T]) Name() string {
+	return r.name
+}
+
+// String returns the name of the role.
+func (r NamedRole[T]) String() string {
+	return r.name
+}
+
+// HeaderRoleExtractor returns a RoleExtractor that decodes the role from the request header by the registry.
+// The header must be set by a trusted party, e.g. an authenticating proxy.
+func HeaderRoleExtractor[T RoleID](registry *RoleRegistry[T], header string) RoleExtractor[T] {
+	return func(r *http.Request) (Role[T], bool) {
+		v := r.Header.Get(header)
+		if v == "" {
+			return nil, false
+		}
+		role, err := registry.Parse(v)
+		return role, err == nil
+	}
+}
+
+// HeaderSubjectExtractor returns a SubjectExtractor that decodes the roles separated by "," from the request header
+// by the registry. The header must be set by a trusted party, e.g. an authenticating proxy.
+func HeaderSubjectExtractor[T RoleID](registry *RoleRegistry[T], header string) SubjectExtractor[T] {
+	return func(r *http.Request) ([]Role[T], bool) {
+		v := r.Header.Get(header)
+		if v == "" {
+			return nil, false
+		}
+		roles, err := registry.ParseAll(v)
+		return roles, err == nil && len(roles) > 0
+	}
+}
+
+// formatRoleID returns the role ID as a string.
+// The uint64 and the Mask roles are formatted as a hexadecimal number.
+func formatRoleID(id interface{}) string {
+	switch v := id.(type) {
+	case uint64:
+		return "0x" + strconv.FormatUint(v, 16)
+	case Mask:
+		return v.String()
+	case string:
+		return v
+	}
+	return fmt.Sprint(id)
+}
+
+// parseRoleNumber parses the number with the "0x" prefix or the decimal number for the uint64 and the Mask roles.
+// The number is the value of the bits, not the index of a bit, e.g. 2 is the bit 1 and 6 are the bits 1 and 2.
+// The decimal number of the Mask roles is limited to the lower 64 bits.
+func parseRoleNumber[T RoleID](s string) (T, bool) {
+	var zero T
+	switch interface{}(zero).(type) {
+	case uint64:
+		if s != "" && s[0] >= '0' && s[0] <= '9' {
+			if v, err := strconv.ParseUint(s, 0, 64); err == nil {
+				return interface{}(v).(T), true
+			}
+		}
+	case Mask:
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			if v, err := ParseMask(s); err == nil {
+				return interface{}(v).(T), true
+			}
+		} else if s != "" && s[0] >= '0' && s[0] <= '9' {
+			if v, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return interface{}(MaskFromUint64(v)).(T), true
+			}
+		}
+	}
+	return zero, false
+}
+
+// overlaps checks if the uint64 or the Mask roles share bits.
+func overlaps[T RoleID](a, b T) bool {
+	switch v := interface{}(a).(type) {
+	case uint64:
+		return v&interface{}(b).(uint64) != 0
+	case Mask:
+		return !v.And(interface{}(b).(Mask)).IsZero()
+	}
+	return false
+}
+
+// isZeroRoleID checks if the uint64 or the Mask role has no bits.
+func isZeroRoleID(id interface{}) bool {
+	switch v := id.(type) {
+	case uint64:
+		return v == 0
+	case Mask:
+		return v.IsZero()
+	}
+	return false
+}
+
+// combineBits returns the union of the uint64 or the Mask roles.
+func combineBits(a, b interface{}) interface{} {
+	switch v := a.(type) {
+	case uint64:
+		return v | b.(uint64)
+	case Mask:
+		return v.Or(b.(Mask))
+	}
+	return a
+}
+
+// clearBits returns the bits of the uint64 or the Mask role a, that are not set in b.
+func clearBits(a, b interface{}) interface{} {
+	switch v := a.(type) {
+	case uint64:
+		return v &^ b.(uint64)
+	case Mask:
+		return v.AndNot(b.(Mask))
+	}
+	return a
+}
@@ -0,0 +1,203 @@
+package rbacinjector
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoleRegistry_Register(t *testing.T) {
+	g := NewRoleRegistry[uint64]()
+	if _, err := g.Register(iRoleAdmin.ID(), "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(iRoleCustomer.ID(), "CUSTOMER"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(iRoleGuest.ID(), "GUEST"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Register(iRoleAdmin.ID(), "SUPERVISOR"); !errors.Is(err, ErrDuplicateRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := g.Register(iRoleRoot.ID(), "ADMIN"); !errors.Is(err, ErrDuplicateRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := g.Register(iRoleAdmin.ID()|iRoleRoot.ID(), "SUPERVISOR"); !errors.Is(err, ErrDuplicateRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := g.Register(iRoleRoot.ID(), "ROOT|ADMIN"); err == nil {
+		t.Errorf("expected error for invalid name")
+	}
+
+	if name, ok := g.Name(iRoleCustomer.ID()); !ok || name != "CUSTOMER" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if r, ok := g.Lookup("ADMIN"); !ok || r.ID() != iRoleAdmin.ID() || r.Name() != "ADMIN" {
+		t.Errorf("unexpected role %v", r)
+	}
+	if roles := g.Roles(); len(roles) != 3 || roles[0].Name() != "ADMIN" || roles[2].Name() != "GUEST" {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
+
+func TestRoleRegistry_FormatParseUINT64(t *testing.T) {
+	g := NewRoleRegistry[uint64]()
+	if _, err := g.Register(iRoleAdmin.ID(), "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(iRoleCustomer.ID(), "CUSTOMER"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		id       uint64
+		expected string
+	}{
+		{iRoleAdmin.ID(), "ADMIN"},
+		{iRoleAdmin.ID() | iRoleCustomer.ID(), "ADMIN|CUSTOMER"},
+		{iRoleAdmin.ID() | iRoleRoot.ID(), "ADMIN|0x8000000000000000"},
+		{iRoleGuest.ID(), "0x0"},
+	}
+	for _, c := range cases {
+		if s := g.Format(c.id); s != c.expected {
+			t.Errorf("unexpected format %s", s)
+		}
+		if r, err := g.Parse(c.expected); err != nil {
+			t.Errorf("unexpected error %s", err)
+		} else if r.ID() != c.id {
+			t.Errorf("unexpected role %v for %s", r.ID(), c.expected)
+		}
+	}
+
+	if _, err := g.Parse("ADMIN|SUPPORT"); !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if roles, err := g.ParseAll("ADMIN, CUSTOMER|16"); err != nil {
+		t.Error(err)
+	} else if len(roles) != 2 || roles[1].ID() != iRoleCustomer.ID() {
+		t.Errorf("unexpected roles %v", roles)
+	}
+
+	var stub *RoleRegistry[uint64]
+	if s := stub.Format(0x12); s != "0x12" {
+		t.Errorf("unexpected format %s", s)
+	}
+	if r, err := stub.Parse("0x12"); err != nil || r.ID() != 0x12 {
+		t.Errorf("unexpected role %v, %v", r, err)
+	}
+}
+
+func TestRoleRegistry_FormatParseMASK(t *testing.T) {
+	g := NewRoleRegistry[Mask]()
+	if _, err := g.Register(mRoleAdmin.ID(), "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(MaskOf(130), "BILLING"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(mRoleCustomer.ID(), "CUSTOMER"); !errors.Is(err, ErrDuplicateRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if s := g.Format(MaskOf(1, 130, 200)); s != "ADMIN|BILLING|"+MaskOf(200).String() {
+		t.Errorf("unexpected format %s", s)
+	}
+	if r, err := g.Parse("BILLING|ADMIN"); err != nil {
+		t.Error(err)
+	} else if r.ID() != MaskOf(1, 130) {
+		t.Errorf("unexpected role %s", r.ID())
+	}
+	if r, err := g.Parse("6"); err != nil {
+		t.Error(err)
+	} else if r.ID() != MaskOf(1, 2) {
+		t.Errorf("unexpected role %s", r.ID())
+	}
+}
+
+func TestRoleRegistry_ParseSTRING(t *testing.T) {
+	g := NewRoleRegistry[string]()
+	if _, err := g.Register("ROLE_ADM", "Administrator"); err != nil {
+		t.Fatal(err)
+	}
+
+	if r, err := g.Parse("Administrator"); err != nil || r.ID() != "ROLE_ADM" {
+		t.Errorf("unexpected role %v, %v", r, err)
+	}
+	if r, err := g.Parse("ROLE_ADM"); err != nil || r.ID() != "ROLE_ADM" {
+		t.Errorf("unexpected role %v, %v", r, err)
+	}
+	if _, err := g.Parse("ROLE_USR"); !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if s := g.Format("ROLE_USR"); s != "ROLE_USR" {
+		t.Errorf("unexpected format %s", s)
+	}
+}
+
+func TestRoleRegistry_ErrorResponseFunc(t *testing.T) {
+	g := NewRoleRegistry[uint64]()
+	if _, err := g.Register(iRoleAdmin.ID(), "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(iRoleCustomer.ID(), "CUSTOMER"); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[uint64](HeaderRoleExtractor[uint64](g, "X-Role"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetRoleRegistry(g)
+	router.SetForbiddenResponseFunc(g.ErrorResponseFunc(http.StatusForbidden))
+	router.HandleFuncAllowFor("/admin", httpStatusNoContent, iRoleAdmin)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("X-Role", "CUSTOMER")
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", res.Code)
+	} else if res.Body.String() != "forbidden: CUSTOMER" {
+		t.Errorf("unexpected body %s", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("X-Role", "ADMIN")
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("X-Role", "SUPPORT")
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+}
+
+func TestHeaderSubjectExtractor(t *testing.T) {
+	g := NewRoleRegistry[string]()
+	e := HeaderSubjectExtractor[string](g, "X-Roles")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Roles", "BILLING, SUPPORT")
+	if _, ok := e(req); ok {
+		t.Errorf("unexpected roles for unknown names")
+	}
+
+	if _, err := g.Register("BILLING", "BILLING"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register("SUPPORT", "SUPPORT"); err != nil {
+		t.Fatal(err)
+	}
+	if roles, ok := e(req); !ok || len(roles) != 2 || roles[1].ID() != "SUPPORT" {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
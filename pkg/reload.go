@@ -0,0 +1,105 @@
+package rbacinjector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ReloadPolicyFile replaces the protection of the registered routes by the entries of the JSON policy file.
+// The format is the one of LoadPolicyFile, the handler of the entry is ignored and the handler
+// of the route is kept. The routes without an entry keep their protection, the candidate policies are kept.
+// The route table is replaced atomically: the requests in flight finish under the previous protection
+// and the new requests are served under the new one.
+// It returns PolicyFileError with the line of the entry, that has no registered route,
+// the route table is not changed in case of an error.
+func (r *HttpRouter[T]) ReloadPolicyFile(rd io.Reader) error {
+	bindings, err := r.decodePolicyFile(rd, nil)
+	if err != nil {
+		return err
+	}
+	return r.rebind(bindings)
+}
+
+// WatchPolicyFile polls the modification time of the JSON policy file with the interval
+// and reloads the policy by ReloadPolicyFile at the first tick and then whenever the file is changed,
+// so the changes made between the load and the watch are not missed.
+// The errors are passed to the errorFunc, the previous protection is kept in that case.
+// It blocks until the context is done and returns the error of the context.
+func (r *HttpRouter[T]) WatchPolicyFile(ctx context.Context, name string, interval time.Duration, errorFunc func(error)) error {
+	var modified time.Time
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			errorFunc(err)
+			continue
+		}
+		if info.ModTime().Equal(modified) {
+			continue
+		}
+		modified = info.ModTime()
+		if err = r.reloadPolicyFile(name); err != nil {
+			errorFunc(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+}
+
+// reloadPolicyFile reloads the policy from the named file.
+func (r *HttpRouter[T]) reloadPolicyFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.ReloadPolicyFile(f)
+}
+
+// rebind replaces the protection of the registered routes by the bindings in a single table swap.
+func (r *HttpRouter[T]) rebind(bindings []routeBinding[T]) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.routes()
+	patterns := patternIndex(current)
+
+	t := make(routeTable[T], len(current))
+	for pattern, e := range current {
+		t[pattern] = e
+	}
+	for _, b := range bindings {
+		pattern, ok := patterns[routeKey(b.pattern)]
+		if !ok {
+			return bindingError(b, fmt.Errorf("%w: %s", ErrRouteNotFound, b.pattern))
+		}
+		if b.mode == ModeUnprotected && r.strict {
+			return bindingError(b, fmt.Errorf("%w: %s", ErrUnprotectedRoute, b.pattern))
+		}
+		authorizer, record := r.protection(b)
+		e := r.newEntry(pattern, current[pattern].origin, authorizer, record)
+		if candidate := current[pattern].shadow; candidate != nil {
+			e = r.shadowEntry(pattern, e, candidate)
+		}
+		t[pattern] = e
+	}
+	r.table.Store(&t)
+	return nil
+}
+
+// bindingError returns the error with the line of the binding, if any.
+func bindingError[T RoleID](b routeBinding[T], err error) error {
+	if b.line > 0 {
+		return &PolicyFileError{Line: b.line, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,214 @@
+package rbacinjector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	stubPolicyAllowCustomer = `[{"pattern": "GET /accounts/{id}", "handler": "getAccount", "mode": "allow", "roles": ["CUSTOMER"]}]`
+	stubPolicyAllowManager  = `[{"pattern": "GET /accounts/{id}", "handler": "getAccount", "mode": "allow", "roles": ["MANAGER"]}]`
+)
+
+func TestHttpRouter_ReloadPolicyFile(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			entered <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	if err = router.LoadPolicyFile(strings.NewReader(stubPolicyAllowCustomer), map[string]http.HandlerFunc{"getAccount": handler}); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(role Role[uint64], target string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, role))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	inflight := make(chan int)
+	go func() { inflight <- serve(iRoleCustomer, "/accounts/1?block=1") }()
+	<-entered
+
+	if err = router.ReloadPolicyFile(strings.NewReader(stubPolicyAllowManager)); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if code := <-inflight; code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d of the in-flight request", code)
+	}
+	if code := serve(iRoleCustomer, "/accounts/1"); code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", code)
+	}
+	if code := serve(iRoleManager, "/accounts/1"); code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", code)
+	}
+	if routes := router.Routes(); len(routes) != 1 || strings.Join(routes[0].RoleNames, ",") != "MANAGER" {
+		t.Errorf("unexpected routes %v", routes)
+	}
+
+	err = router.ReloadPolicyFile(strings.NewReader(`[
+  {"pattern": "GET /accounts/{id}", "handler": "getAccount", "mode": "allow", "roles": ["CUSTOMER"]},
+  {"pattern": "GET /invoices", "handler": "listInvoices", "mode": "allow", "roles": ["CUSTOMER"]}
+]`))
+	var fileErr *PolicyFileError
+	if !errors.As(err, &fileErr) || fileErr.Line != 3 || !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if code := serve(iRoleManager, "/accounts/1"); code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d after the failed reload", code)
+	}
+}
+
+func TestHttpRouter_ReloadPolicyFile_Concurrent(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	if err = router.LoadPolicyFile(strings.NewReader(stubPolicyAllowCustomer), map[string]http.HandlerFunc{"getAccount": httpStatusNoContent}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+				req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleCustomer))
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusNoContent && w.Code != http.StatusForbidden {
+					t.Errorf("unexpected status code %d", w.Code)
+				}
+				router.Routes()
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		policy := stubPolicyAllowCustomer
+		if i%2 == 0 {
+			policy = stubPolicyAllowManager
+		}
+		if err = router.ReloadPolicyFile(strings.NewReader(policy)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestHttpRouter_WatchPolicyFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(name, []byte(stubPolicyAllowCustomer), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = router.LoadPolicyFile(f, map[string]http.HandlerFunc{"getAccount": httpStatusNoContent})
+	_ = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- router.WatchPolicyFile(ctx, name, time.Millisecond, func(err error) { t.Error(err) })
+	}()
+
+	if err = os.WriteFile(name, []byte(stubPolicyAllowManager), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		routes := router.Routes()
+		if len(routes) == 1 && strings.Join(routes[0].RoleNames, ",") == "MANAGER" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("policy file is not reloaded: %v", routes)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHttpRouter_RegisterWhileServing(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	router.dispatch("GET /accounts/{id}").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleAdmin))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusNoContent && w.Code != http.StatusNotFound {
+				t.Errorf("unexpected status code %d", w.Code)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		router.HandleFuncAllowFor(fmt.Sprintf("GET /accounts/{id}/items/%d", i), httpStatusNoContent, iRoleAdmin)
+	}
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin)
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,84 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+)
+
+// ErrorHandler writes the response of the request rejected by the decision.
+// The status of the response is the status of the decision, if the ErrorHandler does not write it.
+type ErrorHandler[T RoleID] func(w http.ResponseWriter, r *http.Request, d Decision[T])
+
+// SetErrorHandler sets the handler that writes the responses of the rejected requests,
+// it takes precedence over the ErrorResponseFunc of the forbidden and the unauthorized requests and the ProblemResponder.
+// The nil ErrorHandler restores them.
+func (r *HttpRouter[T]) SetErrorHandler(h ErrorHandler[T]) {
+	r.errorHandler = h
+}
+
+// reject writes the response of the request rejected by the decision.
+func (r *HttpRouter[T]) reject(w http.ResponseWriter, req *http.Request, d Decision[T]) {
+	h := r.errorHandler
+	if h == nil {
+		var fallback ErrorHandler[T]
+		if p := r.problemResponder; p != nil {
+			fallback = p.ServeError
+		}
+		h = errorHandlerOf[T](r.unauthorizedResponseFunc, r.forbiddenResponseFunc, fallback)
+	}
+	writeError(w, req, d, h)
+}
+
+// errorHandlerOf returns an ErrorHandler that calls the ErrorResponseFunc of the unauthorized
+// or the forbidden requests. The decision is passed to the ErrorResponseFunc within the context.
+// The fallback, if any, is called instead of the nil ErrorResponseFunc.
+func errorHandlerOf[T RoleID](unauthorizedResponseFunc, forbiddenResponseFunc ErrorResponseFunc, fallback ErrorHandler[T]) ErrorHandler[T] {
+	return func(w http.ResponseWriter, r *http.Request, d Decision[T]) {
+		f := forbiddenResponseFunc
+		if d.Status() == http.StatusUnauthorized {
+			f = unauthorizedResponseFunc
+		}
+		switch {
+		case f != nil:
+			f(w, context.WithValue(r.Context(), decisionContextKey{}, d))
+		case fallback != nil:
+			fallback(w, r, d)
+		}
+	}
+}
+
+// writeError is the single write path of the rejected requests:
+// the ErrorHandler is called and the status of the decision is written only if the ErrorHandler did not write it.
+func writeError[T RoleID](w http.ResponseWriter, r *http.Request, d Decision[T], h ErrorHandler[T]) {
+	sw := &statusWriter{ResponseWriter: w}
+	h(sw, r, d)
+	if !sw.written {
+		w.WriteHeader(d.Status())
+	}
+}
+
+// statusWriter is an http.ResponseWriter that tracks if the status is written.
+type statusWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// WriteHeader writes the status once, the repeated calls are ignored.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.written {
+		return
+	}
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body, the status is written implicitly by the first write.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for the http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
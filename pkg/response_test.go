@@ -0,0 +1,103 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_SetErrorHandler(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin)
+
+	var got []Decision[uint64]
+	router.SetErrorHandler(func(w http.ResponseWriter, r *http.Request, d Decision[uint64]) {
+		got = append(got, d)
+		w.Header().Set("X-Request", r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusTeapot)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	cases := []struct {
+		role   Role[uint64]
+		reason Reason
+	}{
+		{iRoleCustomer, ReasonNotInAllowSet},
+		{nil, ReasonNoRole},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		w := &headerCounter{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusTeapot || w.writes != 1 {
+			t.Errorf("unexpected status code %d written %d times", w.Code, w.writes)
+		}
+		if h := w.Header().Get("X-Request"); h != "GET /accounts/1" {
+			t.Errorf("unexpected request %s", h)
+		}
+	}
+	if len(got) != len(cases) {
+		t.Fatalf("unexpected decisions %+v", got)
+	}
+	for i, c := range cases {
+		if d := got[i]; d.Allowed || d.Reason != c.reason || d.Route.Pattern != "GET /accounts/{id}" {
+			t.Errorf("unexpected decision %+v", d)
+		}
+	}
+	if d := got[0]; len(d.Roles) != 1 || d.Roles[0].ID() != iRoleCustomer.ID() || len(d.Required) != 1 || d.Required[0].ID() != iRoleAdmin.ID() {
+		t.Errorf("unexpected roles %+v", d)
+	}
+
+	router.SetErrorHandler(func(w http.ResponseWriter, r *http.Request, d Decision[uint64]) {})
+	w := &headerCounter{ResponseRecorder: httptest.NewRecorder()}
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	if w.Code != http.StatusUnauthorized || w.writes != 1 {
+		t.Errorf("unexpected status code %d written %d times", w.Code, w.writes)
+	}
+}
+
+func TestHttpRouter_ErrorResponseFunc_WritesOnce(t *testing.T) {
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetForbiddenResponseFunc(errorForbidden)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin)
+	if err = router.Wrap(http.HandlerFunc(httpStatusNoContent)); err != nil {
+		t.Fatal(err)
+	}
+	router.SetStrictMode(true)
+
+	for _, path := range []string{"/accounts/1", "/unknown"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleCustomer))
+		w := &headerCounter{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden || w.writes != 1 {
+			t.Errorf("%s: unexpected status code %d written %d times", path, w.Code, w.writes)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != "forbidden" {
+			t.Errorf("%s: unexpected body %s", path, body)
+		}
+	}
+}
+
+// headerCounter counts the calls of WriteHeader.
+type headerCounter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *headerCounter) WriteHeader(status int) {
+	w.writes++
+	w.ResponseRecorder.WriteHeader(status)
+}
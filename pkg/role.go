@@ -8,23 +8,77 @@ import (
 // RoleExtractor is a function that extracts the role from the request.
 type RoleExtractor[T RoleID] func(r *http.Request) (role Role[T], exists bool)
 
+// SubjectExtractor is a function that extracts all roles of the subject from the request.
+type SubjectExtractor[T RoleID] func(r *http.Request) (roles []Role[T], exists bool)
+
+// subjectOf returns a SubjectExtractor that extracts the single role of the subject by the RoleExtractor.
+func subjectOf[T RoleID](roleExtractor RoleExtractor[T]) SubjectExtractor[T] {
+	return func(r *http.Request) ([]Role[T], bool) {
+		role, exists := roleExtractor(r)
+		if !exists || role == nil {
+			return nil, false
+		}
+		return []Role[T]{role}, true
+	}
+}
+
+// compactRoles returns the roles without the nil roles.
+func compactRoles[T RoleID](roles []Role[T]) []Role[T] {
+	for i, role := range roles {
+		if role == nil {
+			c := make([]Role[T], 0, len(roles)-1)
+			c = append(c, roles[:i]...)
+			for _, r := range roles[i+1:] {
+				if r != nil {
+					c = append(c, r)
+				}
+			}
+			return c
+		}
+	}
+	return roles
+}
+
+// Match defines how the roles of the subject are matched against the roles.
+type Match int
+
+const (
+	// MatchAny matches, if at least one role of the subject is contained in the roles.
+	MatchAny Match = iota
+	// MatchAll matches, if the subject holds every role of the roles.
+	MatchAll
+)
+
 // RoleID is the interface that wraps the basic ID method.
-// The ID is an uint64 or a string.
+// The ID is an uint64, a string or a Mask.
 type RoleID interface {
-	uint64 | string
+	uint64 | string | Mask
 }
 
 // Role is the interface that wraps the basic methods.
-// A role is an uint64 or a string.
+// A role is an uint64, a string or a Mask.
 type Role[RID RoleID] interface {
 	ID() RID
 	//Name() string
 	//IN(roles ...Role) bool
 }
 
+// newMatchValidator returns a new roleValidator based on the match and the roles.
+// The roles are expanded by the hierarchy, the hierarchy can be nil.
+func newMatchValidator[RID RoleID](match Match, hierarchy *RoleHierarchy[RID], roles []Role[RID]) roleValidator {
+	if match == MatchAll {
+		groups := make([][]Role[RID], 0, len(roles))
+		for _, r := range roles {
+			groups = append(groups, hierarchy.Expand(r))
+		}
+		return newAllValidator(groups)
+	}
+	return newRoleValidator(hierarchy.Expand(roles...))
+}
+
 // newRoleValidator returns a new roleValidator based on the roles.
-// The roles can be a string or an uint64.
-// The Role is checked bitwise for the uint64 roles.
+// The roles can be a string, an uint64 or a Mask.
+// The Role is checked bitwise for the uint64 and the Mask roles.
 // The Role is case-sensitive for the string roles.
 func newRoleValidator[RID RoleID](roles []Role[RID]) roleValidator {
 	if len(roles) > 0 {
@@ -47,16 +101,50 @@ func newRoleValidator[RID RoleID](roles []Role[RID]) roleValidator {
 			}
 			c := intValidator(a)
 			return &c
+		case Mask:
+			var a Mask
+			for _, r := range roles {
+				if m, ok := interface{}(r.ID()).(Mask); ok {
+					a = a.Or(m)
+				}
+			}
+			c := maskValidator(a)
+			return &c
 		}
 	}
 	c := stubValidator(true)
 	return &c
 }
 
-// roleValidator is the interface that wraps the basic IN method.
+// newAllValidator returns a new roleValidator that requires every group of the roles to be held.
+func newAllValidator[RID RoleID](groups [][]Role[RID]) roleValidator {
+	c := make(allValidator, 0, len(groups))
+	for _, g := range groups {
+		ids := make([]interface{}, 0, len(g))
+		for _, r := range g {
+			ids = append(ids, r.ID())
+		}
+		c = append(c, ids)
+	}
+	return c
+}
+
+// roleValidator is the interface that wraps the basic IN and MATCH methods.
 // The IN method checks if the Role is in the roles.
+// The MATCH method checks if the roles of the subject match the roles.
 type roleValidator interface {
 	IN(RoleID interface{}) bool
+	MATCH(RoleIDs []interface{}) bool
+}
+
+// matchAny checks if at least one of the roles is in the roles of the validator.
+func matchAny(v roleValidator, RoleIDs []interface{}) bool {
+	for _, id := range RoleIDs {
+		if v.IN(id) {
+			return true
+		}
+	}
+	return false
 }
 
 // strValidator is a roleValidator for the string roles.
@@ -72,6 +160,11 @@ func (s strValidator) IN(RoleID interface{}) bool {
 	return false
 }
 
+// MATCH checks if at least one of the roles is in the roles.
+func (s strValidator) MATCH(RoleIDs []interface{}) bool {
+	return matchAny(s, RoleIDs)
+}
+
 // intValidator is a roleValidator for the uint64 roles.
 type intValidator uint64
 
@@ -85,6 +178,60 @@ func (i intValidator) IN(RoleID interface{}) bool {
 	return false
 }
 
+// MATCH checks if at least one of the roles is in the roles.
+func (i intValidator) MATCH(RoleIDs []interface{}) bool {
+	return matchAny(i, RoleIDs)
+}
+
+// allValidator is a roleValidator that requires every group of the roles to be held.
+// A group is held, if at least one role of the group is held.
+// The uint64 and the Mask roles are held bitwise by the union of the roles.
+// The string role is held case-sensitive.
+type allValidator [][]interface{}
+
+// IN checks if the Role holds every group of the roles.
+func (a allValidator) IN(RoleID interface{}) bool {
+	return a.MATCH([]interface{}{RoleID})
+}
+
+// MATCH checks if the roles hold every group of the roles.
+func (a allValidator) MATCH(RoleIDs []interface{}) bool {
+	var mask uint64 = 0
+	var wide Mask
+	for _, id := range RoleIDs {
+		switch v := id.(type) {
+		case uint64:
+			mask |= v
+		case Mask:
+			wide = wide.Or(v)
+		}
+	}
+	for _, group := range a {
+		held := false
+		for _, g := range group {
+			switch v := g.(type) {
+			case uint64:
+				held = (mask & v) == v
+			case Mask:
+				held = wide.Contains(v)
+			default:
+				for _, id := range RoleIDs {
+					if held = id == g; held {
+						break
+					}
+				}
+			}
+			if held {
+				break
+			}
+		}
+		if !held {
+			return false
+		}
+	}
+	return true
+}
+
 // stubValidator is a roleValidator for pass through any roles
 type stubValidator bool
 
@@ -92,3 +239,8 @@ type stubValidator bool
 func (v *stubValidator) IN(_ interface{}) bool {
 	return true
 }
+
+// MATCH grants access for any roles.
+func (v *stubValidator) MATCH(_ []interface{}) bool {
+	return true
+}
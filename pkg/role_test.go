@@ -0,0 +1,76 @@
+package rbacinjector
+
+import (
+	"testing"
+)
+
+func TestRoleValidator_MatchAnyUINT64(t *testing.T) {
+	v := newMatchValidator[uint64](MatchAny, nil, []Role[uint64]{iRoleCustomer, iRoleAdmin})
+
+	if !v.MATCH([]interface{}{iRoleRoot.ID(), iRoleAdmin.ID()}) {
+		t.Errorf("expected match for root and admin")
+	}
+	if v.MATCH([]interface{}{iRoleRoot.ID()}) {
+		t.Errorf("unexpected match for root")
+	}
+	if !v.MATCH([]interface{}{iRoleCustomer.ID() | iRoleAdmin.ID()}) {
+		t.Errorf("expected match for customer|admin")
+	}
+	if v.MATCH([]interface{}{}) {
+		t.Errorf("unexpected match for empty roles")
+	}
+}
+
+func TestRoleValidator_MatchAllUINT64(t *testing.T) {
+	v := newMatchValidator[uint64](MatchAll, nil, []Role[uint64]{iRoleCustomer, iRoleAdmin})
+
+	if !v.MATCH([]interface{}{iRoleCustomer.ID(), iRoleAdmin.ID()}) {
+		t.Errorf("expected match for customer and admin")
+	}
+	if !v.MATCH([]interface{}{iRoleCustomer.ID() | iRoleAdmin.ID() | iRoleRoot.ID()}) {
+		t.Errorf("expected match for customer|admin|root")
+	}
+	if v.MATCH([]interface{}{iRoleAdmin.ID(), iRoleRoot.ID()}) {
+		t.Errorf("unexpected match for admin and root")
+	}
+}
+
+func TestRoleValidator_MatchAnySTRING(t *testing.T) {
+	v := newMatchValidator[string](MatchAny, nil, []Role[string]{sRoleCustomer, sRoleAdmin})
+
+	if !v.MATCH([]interface{}{sRoleRoot.ID(), sRoleAdmin.ID()}) {
+		t.Errorf("expected match for root and admin")
+	}
+	if v.MATCH([]interface{}{sRoleRoot.ID(), sRoleGuest.ID()}) {
+		t.Errorf("unexpected match for root and guest")
+	}
+	if v.MATCH([]interface{}{"admin"}) {
+		t.Errorf("unexpected case-insensitive match")
+	}
+}
+
+func TestRoleValidator_MatchAllSTRING(t *testing.T) {
+	v := newMatchValidator[string](MatchAll, nil, []Role[string]{sRoleCustomer, sRoleAdmin})
+
+	if !v.MATCH([]interface{}{sRoleCustomer.ID(), sRoleRoot.ID(), sRoleAdmin.ID()}) {
+		t.Errorf("expected match for customer, root and admin")
+	}
+	if v.MATCH([]interface{}{sRoleCustomer.ID()}) {
+		t.Errorf("unexpected match for customer")
+	}
+}
+
+func TestRoleValidator_MatchAllHierarchy(t *testing.T) {
+	h := NewRoleHierarchy[string]()
+	if err := h.Inherit(sRoleAdmin, sRoleManager); err != nil {
+		t.Fatal(err)
+	}
+	v := newMatchValidator[string](MatchAll, h, []Role[string]{sRoleCustomer, sRoleManager})
+
+	if !v.MATCH([]interface{}{sRoleCustomer.ID(), sRoleAdmin.ID()}) {
+		t.Errorf("expected match for customer and admin")
+	}
+	if v.MATCH([]interface{}{sRoleAdmin.ID()}) {
+		t.Errorf("unexpected match for admin")
+	}
+}
@@ -11,7 +11,12 @@ type HttpRoute[T RoleID] interface {
 	NextRoute(path ...string) (HttpRoute[T], error)
 	HandleFunc(pattern string, handler http.HandlerFunc) error
 	HandleFuncAllowFor(pattern string, handler http.HandlerFunc, roles ...Role[T]) error
+	HandleFuncAllowForAll(pattern string, handler http.HandlerFunc, roles ...Role[T]) error
 	HandleFuncDenyFor(pattern string, handler http.HandlerFunc, roles ...Role[T]) error
+	HandleFuncDenyForAll(pattern string, handler http.HandlerFunc, roles ...Role[T]) error
+	HandleFuncWithPolicy(pattern string, handler http.HandlerFunc, policy Policy[T]) error
+	HandleFuncRequire(pattern string, handler http.HandlerFunc, permissions ...Permission) error
+	HandleFuncWithAuthorizer(pattern string, handler http.HandlerFunc, authorizer Authorizer[T]) error
 }
 
 // httpRoute is a struct that implements the HttpRoute interface.
@@ -55,8 +60,7 @@ func (r *httpRoute[T]) HandleFunc(pattern string, handler http.HandlerFunc) erro
 	if err != nil {
 		return err
 	}
-	r.server.HandleFunc(p, handler)
-	return nil
+	return r.server.HandleFunc(p, handler)
 }
 
 func (r *httpRoute[T]) HandleFuncAllowFor(pattern string, handler http.HandlerFunc, roles ...Role[T]) error {
@@ -68,6 +72,15 @@ func (r *httpRoute[T]) HandleFuncAllowFor(pattern string, handler http.HandlerFu
 	return nil
 }
 
+func (r *httpRoute[T]) HandleFuncAllowForAll(pattern string, handler http.HandlerFunc, roles ...Role[T]) error {
+	p, err := r.Pattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.server.HandleFuncAllowForAll(p, handler, roles...)
+	return nil
+}
+
 func (r *httpRoute[T]) HandleFuncDenyFor(pattern string, handler http.HandlerFunc, roles ...Role[T]) error {
 	p, err := r.Pattern(pattern)
 	if err != nil {
@@ -77,6 +90,45 @@ func (r *httpRoute[T]) HandleFuncDenyFor(pattern string, handler http.HandlerFun
 	return nil
 }
 
+func (r *httpRoute[T]) HandleFuncDenyForAll(pattern string, handler http.HandlerFunc, roles ...Role[T]) error {
+	p, err := r.Pattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.server.HandleFuncDenyForAll(p, handler, roles...)
+	return nil
+}
+
+func (r *httpRoute[T]) HandleFuncWithPolicy(pattern string, handler http.HandlerFunc, policy Policy[T]) error {
+	p, err := r.Pattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.server.HandleFuncWithPolicy(p, handler, policy)
+	return nil
+}
+
+func (r *httpRoute[T]) HandleFuncRequire(pattern string, handler http.HandlerFunc, permissions ...Permission) error {
+	p, err := r.Pattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.server.HandleFuncRequire(p, handler, permissions...)
+	return nil
+}
+
+func (r *httpRoute[T]) HandleFuncWithAuthorizer(pattern string, handler http.HandlerFunc, authorizer Authorizer[T]) error {
+	if authorizer == nil {
+		return ErrNilAuthorizer
+	}
+	p, err := r.Pattern(pattern)
+	if err != nil {
+		return err
+	}
+	r.server.HandleFuncWithAuthorizer(p, handler, authorizer)
+	return nil
+}
+
 func (r *httpRoute[T]) Pattern(pattern ...string) (string, error) {
 	// extract method and url path
 	method := ""
@@ -2,64 +2,316 @@ package rbacinjector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrUnprotectedRoute is returned when a handler is registered without a policy by the strict HttpRouter.
+var ErrUnprotectedRoute = errors.New("unprotected route")
+
+// ErrNilAuthorizer is returned when the route is registered with the nil Authorizer.
+var ErrNilAuthorizer = errors.New("nil authorizer")
+
 // NewHttpRouter returns a new HttpRouter.
 // The HttpRouter is used to configure the server.
 // The HttpRouter is an HTTP request multiplexer.
 func NewHttpRouter[T RoleID](roleExtractor RoleExtractor[T]) (*HttpRouter[T], error) {
+	return NewHttpSubjectRouter[T](subjectOf(roleExtractor))
+}
+
+// NewHttpSubjectRouter returns a new HttpRouter for the subjects with multiple roles.
+// The HttpRouter is used to configure the server.
+// The HttpRouter is an HTTP request multiplexer.
+func NewHttpSubjectRouter[T RoleID](subjectExtractor SubjectExtractor[T]) (*HttpRouter[T], error) {
 	r := &HttpRouter[T]{
-		roleExtractor:            roleExtractor,
-		forbiddenResponseFunc:    func(w http.ResponseWriter, _ context.Context) { w.WriteHeader(http.StatusForbidden) },
-		unauthorizedResponseFunc: func(w http.ResponseWriter, _ context.Context) { w.WriteHeader(http.StatusUnauthorized) },
-		ServeMux:                 http.NewServeMux(),
+		subjectExtractor: subjectExtractor,
+		permissions:      NewRolePermissions[T](),
+		registry:         NewRoleRegistry[T](),
+		problemResponder: NewProblemResponder[T](),
+		mux:              http.NewServeMux(),
 	}
+	r.table.Store(&routeTable[T]{})
 	return r, nil
 }
 
 // HttpRouter is an HTTP request multiplexer.
+// The HttpRouter owns its mux, so every handler is registered through the HttpRouter
+// and its protection status is recorded.
+// The protection of the routes is held in the immutable table, that is replaced atomically
+// on every registration and reload, so each request is served under a single snapshot.
 type HttpRouter[T RoleID] struct {
-	roleExtractor            RoleExtractor[T]
+	subjectExtractor         SubjectExtractor[T]
 	forbiddenResponseFunc    ErrorResponseFunc
 	unauthorizedResponseFunc ErrorResponseFunc
-	*http.ServeMux
+	hierarchy                *RoleHierarchy[T]
+	permissions              *RolePermissions[T]
+	registry                 *RoleRegistry[T]
+	shadowReporter           ShadowReporter[T]
+	auditHook                AuditHook
+	metrics                  *Metrics
+	spanExporter             SpanExporter
+	errorHandler             ErrorHandler[T]
+	problemResponder         *ProblemResponder[T]
+	strict                   bool
+	mutex                    sync.Mutex
+	table                    atomic.Pointer[routeTable[T]]
+	mux                      *http.ServeMux
 }
 
 // SetForbiddenResponseFunc sets the function that is called when the role is not contained in the roles.
+// The nil function restores the ProblemResponder.
 func (r *HttpRouter[T]) SetForbiddenResponseFunc(f ErrorResponseFunc) {
 	r.forbiddenResponseFunc = f
 }
 
 // SetUnauthorizedResponseFunc sets the function that is called when the role is not found.
+// The nil function restores the ProblemResponder.
 func (r *HttpRouter[T]) SetUnauthorizedResponseFunc(f ErrorResponseFunc) {
 	r.unauthorizedResponseFunc = f
 }
 
+// SetStrictMode sets the deny-by-default mode of the router.
+// In the strict mode every registration must state a policy, HandleFunc and Handle return ErrUnprotectedRoute,
+// and ServeHTTP refuses the requests matched by any pattern without a policy.
+func (r *HttpRouter[T]) SetStrictMode(strict bool) {
+	r.strict = strict
+}
+
+// SetRoleHierarchy sets the hierarchy that is applied to the roles of the handlers registered after the call.
+func (r *HttpRouter[T]) SetRoleHierarchy(h *RoleHierarchy[T]) {
+	r.hierarchy = h
+}
+
+// SetRoleRegistry sets the registry that names the roles in the decoded policies and the exported tables.
+func (r *HttpRouter[T]) SetRoleRegistry(g *RoleRegistry[T]) {
+	r.registry = g
+}
+
+// Permissions returns the mapping of the roles to the permissions.
+// The mapping is resolved at request time, so the changes apply to the registered handlers.
+func (r *HttpRouter[T]) Permissions() *RolePermissions[T] {
+	return r.permissions
+}
+
 // HandleFuncAllowFor registers the handler for the given pattern.
-// The handler is called for HTTP requests, if the role is contained in the roles.
+// The handler is called for HTTP requests, if any role of the subject is contained in the roles.
+// The roles are expanded by the roles that inherit them.
 func (r *HttpRouter[T]) HandleFuncAllowFor(pattern string, handler http.HandlerFunc, roles ...Role[T]) {
-	f := AllowFor[T](
-		r.roleExtractor,
-		handler,
-		r.unauthorizedResponseFunc,
-		r.forbiddenResponseFunc,
-		roles...,
-	)
-	r.ServeMux.HandleFunc(pattern, f)
+	r.protect(pattern, handler, r.rolesAuthorizer(ModeAllow, roles), RouteRecord[T]{Mode: ModeAllow, Roles: roles})
+}
+
+// HandleFuncAllowForAll registers the handler for the given pattern.
+// The handler is called for HTTP requests, if the subject holds every role of the roles.
+// Each role is expanded by the roles that inherit it.
+func (r *HttpRouter[T]) HandleFuncAllowForAll(pattern string, handler http.HandlerFunc, roles ...Role[T]) {
+	r.protect(pattern, handler, r.rolesAuthorizer(ModeAllowAll, roles), RouteRecord[T]{Mode: ModeAllowAll, Roles: roles})
 }
 
 // HandleFuncDenyFor registers the handler for the given pattern.
-// The handler is called for HTTP requests, if the role is not contained in the roles.
+// The handler is called for HTTP requests, if no role of the subject is contained in the roles.
+// The roles are expanded by the roles that inherit them.
 func (r *HttpRouter[T]) HandleFuncDenyFor(pattern string, handler http.HandlerFunc, roles ...Role[T]) {
-	f := DenyFor[T](
-		r.roleExtractor,
-		handler,
-		r.unauthorizedResponseFunc,
-		r.forbiddenResponseFunc,
-		roles...,
-	)
-	r.ServeMux.HandleFunc(pattern, f)
+	r.protect(pattern, handler, r.rolesAuthorizer(ModeDeny, roles), RouteRecord[T]{Mode: ModeDeny, Roles: roles})
+}
+
+// HandleFuncDenyForAll registers the handler for the given pattern.
+// The handler is called for HTTP requests, if the subject does not hold every role of the roles.
+// Each role is expanded by the roles that inherit it.
+func (r *HttpRouter[T]) HandleFuncDenyForAll(pattern string, handler http.HandlerFunc, roles ...Role[T]) {
+	r.protect(pattern, handler, r.rolesAuthorizer(ModeDenyAll, roles), RouteRecord[T]{Mode: ModeDenyAll, Roles: roles})
+}
+
+// HandleFunc registers the handler without any role check for the given pattern.
+// It returns ErrUnprotectedRoute in the strict mode, use the Public policy for the public routes.
+func (r *HttpRouter[T]) HandleFunc(pattern string, handler http.HandlerFunc) error {
+	if r.strict {
+		return fmt.Errorf("%w: %s", ErrUnprotectedRoute, pattern)
+	}
+	r.register(pattern, handler, nil, RouteRecord[T]{Mode: ModeUnprotected})
+	return nil
+}
+
+// Handle registers the handler without any role check for the given pattern.
+// It returns ErrUnprotectedRoute in the strict mode, use the Public policy for the public routes.
+func (r *HttpRouter[T]) Handle(pattern string, handler http.Handler) error {
+	if r.strict {
+		return fmt.Errorf("%w: %s", ErrUnprotectedRoute, pattern)
+	}
+	r.register(pattern, handler, nil, RouteRecord[T]{Mode: ModeUnprotected})
+	return nil
+}
+
+// Wrap sets the existing handler, e.g. the mux the team already has, that serves the requests
+// which match no pattern of the router. The wrapped handler is unprotected.
+// It returns ErrUnprotectedRoute in the strict mode.
+func (r *HttpRouter[T]) Wrap(handler http.Handler) error {
+	if r.strict {
+		return fmt.Errorf("%w: wrapped handler", ErrUnprotectedRoute)
+	}
+
+	e := r.newEntry("", handler, nil, RouteRecord[T]{Mode: ModeUnprotected})
+	r.update(func(t routeTable[T]) {
+		t[""] = e
+	})
+	return nil
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern most closely matches the request URL.
+// The request that matches no pattern is dispatched to the wrapped handler, if any.
+// In the strict mode the request matched by the pattern without a policy is refused as forbidden.
+func (r *HttpRouter[T]) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.mux.Handler(req); pattern == "" {
+		if e, ok := r.routes()[""]; ok {
+			r.serve(w, req, e)
+			return
+		}
+	}
+	r.mux.ServeHTTP(w, req)
+}
+
+// HandleFuncWithPolicy registers the handler for the given pattern.
+// The handler is called for HTTP requests, if the roles of the subject satisfy the policy.
+// The handler is called for any HTTP request, if the policy is Public.
+// The role sets of the policy are expanded by the roles that inherit them.
+func (r *HttpRouter[T]) HandleFuncWithPolicy(pattern string, handler http.HandlerFunc, policy Policy[T]) {
+	if policy.op == policyPublic {
+		r.register(pattern, handler, nil, RouteRecord[T]{Mode: ModePublic})
+		return
+	}
+	validator := policy.compile(r.hierarchy)
+	authorizer := newValidatorAuthorizer[T](validator, true, ReasonPolicyNotSatisfied, nil)
+	r.protect(pattern, handler, authorizer, RouteRecord[T]{Mode: ModePolicy, policy: &policy})
+}
+
+// HandleFuncRequire registers the handler for the given pattern.
+// The handler is called for HTTP requests, if the roles of the subject are granted every permission.
+// The roles are expanded by the roles they inherit.
+func (r *HttpRouter[T]) HandleFuncRequire(pattern string, handler http.HandlerFunc, permissions ...Permission) {
+	r.protect(pattern, handler, r.permissionAuthorizer(permissions), RouteRecord[T]{Mode: ModePermission, Permissions: permissions})
+}
+
+// HandleFuncWithAuthorizer registers the handler for the given pattern.
+// The handler is called for HTTP requests, if the authorizer allows the subject.
+// It panics, if the authorizer is nil.
+func (r *HttpRouter[T]) HandleFuncWithAuthorizer(pattern string, handler http.HandlerFunc, authorizer Authorizer[T]) {
+	if authorizer == nil {
+		panic(fmt.Errorf("%w: %s", ErrNilAuthorizer, pattern))
+	}
+	r.protect(pattern, handler, authorizer, RouteRecord[T]{Mode: ModeAuthorizer})
+}
+
+// protect registers the handler for the given pattern, that is protected by the authorizer.
+func (r *HttpRouter[T]) protect(pattern string, handler http.HandlerFunc, authorizer Authorizer[T], record RouteRecord[T]) {
+	r.register(pattern, handler, authorizer, record)
+}
+
+// register registers the handler for the given pattern and records the route.
+// The mux dispatches the requests through the route table, so the protection can be replaced later.
+// The request matched by the pattern before its entry is stored is not found.
+func (r *HttpRouter[T]) register(pattern string, handler http.Handler, authorizer Authorizer[T], record RouteRecord[T]) {
+	e := r.newEntry(pattern, handler, authorizer, record)
+	r.mux.Handle(pattern, r.dispatch(pattern))
+	r.update(func(t routeTable[T]) {
+		t[pattern] = e
+	})
+}
+
+// newEntry returns a new route entry for the handler, that is protected by the authorizer.
+// The nil authorizer leaves the handler open, it is allowed only for the public and the unprotected routes,
+// otherwise newEntry panics as the protected route can not fail open. The empty pattern is the wrapped handler.
+// The subject of the public and the unprotected routes is extracted only if the decision is observed.
+func (r *HttpRouter[T]) newEntry(pattern string, handler http.Handler, authorizer Authorizer[T], record RouteRecord[T]) *routeEntry[T] {
+	if authorizer == nil && record.Mode != ModePublic && record.Mode != ModeUnprotected {
+		panic(fmt.Errorf("%w: %s route %s", ErrNilAuthorizer, record.Mode, pattern))
+	}
+	if pattern != "" {
+		record.RouteInfo = newRouteInfo(pattern)
+	}
+	record.Handler = handlerName(handler)
+	e := &routeEntry[T]{
+		record:     record,
+		origin:     handler,
+		handler:    handler,
+		authorizer: authorizer,
+	}
+	if authorizer != nil {
+		e.handler = processSubject[T](
+			r.extract,
+			handler.ServeHTTP,
+			r.reject,
+			authorizer,
+			record.RouteInfo,
+			r.observe,
+		)
+		return e
+	}
+
+	reason := openReason(record.Mode)
+	e.handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.observed() {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		start := time.Now()
+		d := decide[T](r.extract, nil, req, record.RouteInfo)
+		d.Reason = reason
+		r.observe(req, d, time.Since(start))
+		handler.ServeHTTP(w, req)
+	})
+	return e
+}
+
+// rolesAuthorizer returns the Authorizer of the allow or the deny mode for the roles.
+// The roles are expanded by the roles that inherit them.
+func (r *HttpRouter[T]) rolesAuthorizer(mode Mode, roles []Role[T]) Authorizer[T] {
+	match := MatchAny
+	if mode == ModeAllowAll || mode == ModeDenyAll {
+		match = MatchAll
+	}
+	validator := newMatchValidator(match, r.hierarchy, roles)
+	if mode == ModeAllow || mode == ModeAllowAll {
+		return newValidatorAuthorizer(validator, true, ReasonNotInAllowSet, roles)
+	}
+	return newValidatorAuthorizer(validator, false, ReasonInDenySet, roles)
+}
+
+// permissionAuthorizer returns the Authorizer, that requires every permission.
+// The roles are expanded by the roles they inherit.
+func (r *HttpRouter[T]) permissionAuthorizer(permissions []Permission) Authorizer[T] {
+	validator := &permissionValidator[T]{
+		permissions: r.permissions,
+		hierarchy:   r.hierarchy,
+		required:    permissions,
+	}
+	return newValidatorAuthorizer[T](validator, true, ReasonMissingPermission, nil)
+}
+
+// dispatch returns the handler of the mux, that serves the request by the current entry of the pattern.
+func (r *HttpRouter[T]) dispatch(pattern string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e, ok := r.routes()[pattern]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		r.serve(w, req, e)
+	})
+}
+
+// serve serves the request by the route entry.
+// In the strict mode the request matched by the pattern without a policy is refused as forbidden.
+func (r *HttpRouter[T]) serve(w http.ResponseWriter, req *http.Request, e *routeEntry[T]) {
+	if r.strict && !e.record.Protected() {
+		d := Decision[T]{Reason: ReasonNoPolicy, Route: e.record.RouteInfo}
+		r.observe(req, d, 0)
+		r.reject(w, req, d)
+		return
+	}
+	e.handler.ServeHTTP(w, req)
 }
 
 // NewRoute returns a new HttpRoute.
@@ -77,6 +329,27 @@ func DenyFor[T RoleID](roleExtractor RoleExtractor[T], handler http.HandlerFunc,
 	return process[T](false, roleExtractor, handler, unauthorizedResponseFunc, forbiddenResponseFunc, roles...)
 }
 
+// AllowForSubject returns a new handler that checks if the roles of the subject match the roles.
+func AllowForSubject[T RoleID](subjectExtractor SubjectExtractor[T], handler http.HandlerFunc, unauthorizedResponseFunc ErrorResponseFunc, forbiddenResponseFunc ErrorResponseFunc, match Match, roles ...Role[T]) http.HandlerFunc {
+	authorizer := newValidatorAuthorizer(newMatchValidator(match, nil, roles), true, ReasonNotInAllowSet, roles)
+	return processSubject[T](subjectExtractor, handler, errorHandlerOf[T](unauthorizedResponseFunc, forbiddenResponseFunc, nil), authorizer, RouteInfo{}, nil)
+}
+
+// DenyForSubject returns a new handler that checks if the roles of the subject do not match the roles.
+func DenyForSubject[T RoleID](subjectExtractor SubjectExtractor[T], handler http.HandlerFunc, unauthorizedResponseFunc ErrorResponseFunc, forbiddenResponseFunc ErrorResponseFunc, match Match, roles ...Role[T]) http.HandlerFunc {
+	authorizer := newValidatorAuthorizer(newMatchValidator(match, nil, roles), false, ReasonInDenySet, roles)
+	return processSubject[T](subjectExtractor, handler, errorHandlerOf[T](unauthorizedResponseFunc, forbiddenResponseFunc, nil), authorizer, RouteInfo{}, nil)
+}
+
+// AuthorizeWith returns a new handler that checks if the authorizer allows the subject.
+// It panics, if the authorizer is nil.
+func AuthorizeWith[T RoleID](subjectExtractor SubjectExtractor[T], handler http.HandlerFunc, unauthorizedResponseFunc ErrorResponseFunc, forbiddenResponseFunc ErrorResponseFunc, authorizer Authorizer[T]) http.HandlerFunc {
+	if authorizer == nil {
+		panic(ErrNilAuthorizer)
+	}
+	return processSubject[T](subjectExtractor, handler, errorHandlerOf[T](unauthorizedResponseFunc, forbiddenResponseFunc, nil), authorizer, RouteInfo{}, nil)
+}
+
 // process returns a new handler that checks if the role is contained in the roles.
 func process[T RoleID](
 	expected bool,
@@ -86,20 +359,84 @@ func process[T RoleID](
 	forbiddenResponseFunc ErrorResponseFunc,
 	roles ...Role[T],
 ) http.HandlerFunc {
-	validator := newRoleValidator(roles)
+	reason := ReasonNotInAllowSet
+	if !expected {
+		reason = ReasonInDenySet
+	}
+	authorizer := newValidatorAuthorizer(newRoleValidator(roles), expected, reason, roles)
+	return processSubject[T](subjectOf(roleExtractor), handler, errorHandlerOf[T](unauthorizedResponseFunc, forbiddenResponseFunc, nil), authorizer, RouteInfo{}, nil)
+}
+
+// processSubject returns a new handler that checks if the authorizer allows the subject.
+// The decision that rejects the request is passed to the ErrorHandler.
+// The decision is passed to the observer, if any, before the request is served or rejected.
+func processSubject[T RoleID](
+	subjectExtractor SubjectExtractor[T],
+	handler http.HandlerFunc,
+	errorHandler ErrorHandler[T],
+	authorizer Authorizer[T],
+	route RouteInfo,
+	observer decisionObserver[T],
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if role, exists := roleExtractor(r); !exists || role == nil {
-			unauthorizedResponseFunc(w, r.Context())
-			w.WriteHeader(http.StatusUnauthorized)
-			return
-		} else if validator.IN(role.ID()) != expected {
-			forbiddenResponseFunc(w, r.Context())
-			w.WriteHeader(http.StatusForbidden)
-			return
+		var start time.Time
+		if observer != nil {
+			start = time.Now()
+		}
+		decision := decide(subjectExtractor, authorizer, r, route)
+		var latency time.Duration
+		if observer != nil {
+			latency = time.Since(start)
 		}
-		handler(w, r)
+		enforce(w, r, decision, latency, handler, errorHandler, observer)
 	}
 }
 
+// enforce passes the decision to the observer, if any, then serves the request or rejects it by the ErrorHandler.
+func enforce[T RoleID](
+	w http.ResponseWriter,
+	r *http.Request,
+	decision Decision[T],
+	latency time.Duration,
+	handler http.HandlerFunc,
+	errorHandler ErrorHandler[T],
+	observer decisionObserver[T],
+) {
+	if observer != nil {
+		observer(r, decision, latency)
+	}
+	if !decision.Allowed {
+		writeError(w, r, decision, errorHandler)
+		return
+	}
+	handler(w, r)
+}
+
+// openReason returns the reason of the decision of the route without an authorizer.
+func openReason(mode Mode) Reason {
+	if mode == ModeUnprotected {
+		return ReasonUnprotected
+	}
+	return ReasonPublic
+}
+
+// decide returns the decision of the authorizer for the subject of the request.
+// The subject without roles is rejected with ReasonNoRole, the nil authorizer of the public
+// and the unprotected routes grants any subject.
+func decide[T RoleID](subjectExtractor SubjectExtractor[T], authorizer Authorizer[T], r *http.Request, route RouteInfo) Decision[T] {
+	roles, exists := subjectExtractor(r)
+	roles = compactRoles(roles)
+	switch {
+	case authorizer == nil:
+		return Decision[T]{Allowed: true, Reason: ReasonGranted, Roles: roles, Route: route}
+	case !exists || len(roles) == 0:
+		return Decision[T]{Reason: ReasonNoRole, Route: route}
+	}
+	return authorizer.Authorize(r, roles, route)
+}
+
+// decisionObserver observes the decision made for the request and the time spent to make it.
+type decisionObserver[T RoleID] func(r *http.Request, d Decision[T], latency time.Duration)
+
 // ErrorResponseFunc is a function that writes an error response.
 type ErrorResponseFunc func(w http.ResponseWriter, ctx context.Context)
@@ -2,6 +2,7 @@ package rbacinjector
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -151,6 +152,28 @@ func BenchmarkProcessINT(b *testing.B) {
 	}
 }
 
+// 2026-10-17: BenchmarkProcessINT  (baseline) 4212492               563.8 ns/op         504 B/op         11 allocs/op
+// 2026-10-17: BenchmarkProcessINT             2496595               942.0 ns/op         728 B/op         15 allocs/op
+// 2026-10-17: BenchmarkProcessMASK            2671917              1250 ns/op           728 B/op         15 allocs/op
+// The validators match the single role without allocations, the remaining cost over the baseline is
+// the subject slice, the Decision passed to the ErrorResponseFunc within the context and the single write path.
+func BenchmarkProcessMASK(b *testing.B) {
+	f := process[Mask](true, extractorMASK, httpStatusNoContent, errorUnauthorized, errorForbidden, mRoleCustomer, mRoleAdmin, mRoleGuest)
+
+	reqAdmin := httptest.NewRequest("GET", "/process", nil)
+	reqAdmin = reqAdmin.WithContext(context.WithValue(reqAdmin.Context(), contextRoleKey, mRoleAdmin))
+
+	reqRoot := httptest.NewRequest("GET", "/process", nil)
+	reqRoot = reqRoot.WithContext(context.WithValue(reqRoot.Context(), contextRoleKey, mRoleRoot))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		f(httptest.NewRecorder(), reqAdmin)
+		f(httptest.NewRecorder(), reqRoot)
+	}
+}
+
 const (
 	sRoleRoot     = stubRoleSTR("ROOT")
 	sRoleAdmin    = stubRoleSTR("ADMIN")
@@ -295,6 +318,114 @@ func BenchmarkProcessSTR(b *testing.B) {
 	}
 }
 
+func TestHttpRouter_StrictMode(t *testing.T) {
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	route, err := router.NewRoute("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFunc("/legacy", httpStatusNoContent); err != nil {
+		t.Fatal(err)
+	}
+	router.SetStrictMode(true)
+
+	if err = router.HandleFunc("/open", httpStatusNoContent); !errors.Is(err, ErrUnprotectedRoute) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err = router.Handle("/open", http.HandlerFunc(httpStatusNoContent)); !errors.Is(err, ErrUnprotectedRoute) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err = route.HandleFunc("/open", httpStatusNoContent); !errors.Is(err, ErrUnprotectedRoute) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err = route.HandleFuncWithPolicy("/public", httpStatusNoContent, Public[string]()); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncAllowFor("/admin", httpStatusNoContent, sRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err = router.Wrap(http.NotFoundHandler()); !errors.Is(err, ErrUnprotectedRoute) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	cases := []struct {
+		path     string
+		role     Role[string]
+		expected int
+	}{
+		{"/v1/public", nil, http.StatusNoContent},
+		{"/v1/admin", sRoleAdmin, http.StatusNoContent},
+		{"/v1/admin", nil, http.StatusUnauthorized},
+		{"/v1/legacy", sRoleAdmin, http.StatusForbidden},
+		{"/v1/open", sRoleAdmin, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		router.ServeHTTP(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %s", res.Code, c.path)
+		}
+	}
+
+	router.SetStrictMode(false)
+	if err = router.HandleFunc("/open", httpStatusNoContent); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHttpRouter_Wrap(t *testing.T) {
+	legacy := http.NewServeMux()
+	legacy.HandleFunc("/legacy", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	router, err := NewHttpRouter[string](extractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = router.Wrap(legacy); err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncAllowFor("/admin", httpStatusNoContent, sRoleAdmin)
+
+	cases := []struct {
+		path     string
+		role     Role[string]
+		expected int
+	}{
+		{"/admin", sRoleAdmin, http.StatusNoContent},
+		{"/admin", sRoleCustomer, http.StatusForbidden},
+		{"/legacy", nil, http.StatusAccepted},
+		{"/unknown", nil, http.StatusNotFound},
+	}
+	serve := func() {
+		for _, c := range cases {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", c.path, nil)
+			if c.role != nil {
+				req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+			}
+			router.ServeHTTP(res, req)
+			if res.Code != c.expected {
+				t.Errorf("unexpected status code %d for %s", res.Code, c.path)
+			}
+		}
+	}
+	serve()
+
+	router.SetStrictMode(true)
+	cases[2].expected = http.StatusForbidden
+	cases[3].expected = http.StatusForbidden
+	serve()
+}
+
 func httpStatusNoContent(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -334,3 +465,105 @@ func errorForbidden(w http.ResponseWriter, _ context.Context) {
 	w.WriteHeader(http.StatusForbidden)
 	_, _ = w.Write([]byte("forbidden"))
 }
+
+func TestHttpRouter_SubjectSTRING(t *testing.T) {
+	router, err := NewHttpSubjectRouter[string](subjectExtractorSTR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncAllowFor("/allow/any", httpStatusNoContent, sRoleCustomer, sRoleAdmin)
+	router.HandleFuncAllowForAll("/allow/all", httpStatusNoContent, sRoleCustomer, sRoleAdmin)
+	router.HandleFuncDenyFor("/deny/any", httpStatusNoContent, sRoleCustomer, sRoleAdmin)
+	router.HandleFuncDenyForAll("/deny/all", httpStatusNoContent, sRoleCustomer, sRoleAdmin)
+
+	cases := []struct {
+		path     string
+		roles    []Role[string]
+		expected int
+	}{
+		{"/allow/any", []Role[string]{sRoleRoot, sRoleAdmin}, http.StatusNoContent},
+		{"/allow/any", []Role[string]{sRoleRoot, sRoleGuest}, http.StatusForbidden},
+		{"/allow/all", []Role[string]{sRoleCustomer, sRoleAdmin}, http.StatusNoContent},
+		{"/allow/all", []Role[string]{sRoleRoot, sRoleAdmin}, http.StatusForbidden},
+		{"/deny/any", []Role[string]{sRoleRoot, sRoleAdmin}, http.StatusForbidden},
+		{"/deny/any", []Role[string]{sRoleRoot, sRoleGuest}, http.StatusNoContent},
+		{"/deny/all", []Role[string]{sRoleCustomer, sRoleAdmin}, http.StatusForbidden},
+		{"/deny/all", []Role[string]{sRoleRoot, sRoleAdmin}, http.StatusNoContent},
+		{"/allow/any", nil, http.StatusUnauthorized},
+		{"/deny/any", []Role[string]{}, http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.roles != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.roles))
+		}
+		router.ServeHTTP(res, req)
+		if res.Code != c.expected {
+			t.Errorf("unexpected status code %d for %s %v", res.Code, c.path, c.roles)
+		}
+	}
+}
+
+func TestAllowForSubjectUINT64(t *testing.T) {
+	f := AllowForSubject[uint64](subjectExtractorINT, httpStatusNoContent, errorUnauthorized, errorForbidden, MatchAll, iRoleCustomer, iRoleAdmin)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/allow", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, []Role[uint64]{iRoleAdmin, iRoleCustomer}))
+	f(res, req)
+	if res.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/allow", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, []Role[uint64]{iRoleAdmin}))
+	f(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", res.Code)
+	} else if res.Body.String() != "forbidden" {
+		t.Errorf("unexpected body %s", res.Body.String())
+	}
+}
+
+func TestDenyForSubjectUINT64(t *testing.T) {
+	f := DenyForSubject[uint64](subjectExtractorINT, httpStatusNoContent, errorUnauthorized, errorForbidden, MatchAny, iRoleCustomer, iRoleAdmin)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/deny", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, []Role[uint64]{iRoleRoot, iRoleCustomer}))
+	f(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/deny", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, []Role[uint64]{iRoleRoot}))
+	f(res, req)
+	if res.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/deny", nil)
+	f(res, req)
+	if res.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status code %d", res.Code)
+	} else if res.Body.String() != "unauthorized" {
+		t.Errorf("unexpected body %s", res.Body.String())
+	}
+}
+
+func subjectExtractorINT(r *http.Request) (roles []Role[uint64], exists bool) {
+	val := r.Context().Value(contextRoleKey)
+	roles, exists = val.([]Role[uint64])
+	return
+}
+
+func subjectExtractorSTR(r *http.Request) (roles []Role[string], exists bool) {
+	val := r.Context().Value(contextRoleKey)
+	roles, exists = val.([]Role[string])
+	return
+}
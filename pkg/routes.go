@@ -0,0 +1,126 @@
+package rbacinjector
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// Mode is the protection mode of the registered route.
+type Mode string
+
+const (
+	// ModeAllow is the mode of the routes registered by HandleFuncAllowFor.
+	ModeAllow Mode = "allow"
+	// ModeAllowAll is the mode of the routes registered by HandleFuncAllowForAll.
+	ModeAllowAll Mode = "allow-all"
+	// ModeDeny is the mode of the routes registered by HandleFuncDenyFor.
+	ModeDeny Mode = "deny"
+	// ModeDenyAll is the mode of the routes registered by HandleFuncDenyForAll.
+	ModeDenyAll Mode = "deny-all"
+	// ModePolicy is the mode of the routes registered by HandleFuncWithPolicy.
+	ModePolicy Mode = "policy"
+	// ModePermission is the mode of the routes registered by HandleFuncRequire.
+	ModePermission Mode = "permission"
+	// ModeAuthorizer is the mode of the routes registered by HandleFuncWithAuthorizer.
+	ModeAuthorizer Mode = "authorizer"
+	// ModePublic is the mode of the routes registered by HandleFuncWithPolicy with the Public policy.
+	ModePublic Mode = "public"
+	// ModeUnprotected is the mode of the routes registered by HandleFunc, Handle and Wrap.
+	ModeUnprotected Mode = "unprotected"
+)
+
+// RouteRecord is the record of the handler registered through the HttpRouter or the HttpRoute.
+// The wrapped handler is recorded with the empty pattern.
+type RouteRecord[T RoleID] struct {
+	RouteInfo
+	// Mode is the protection mode of the route.
+	Mode Mode `json:"mode"`
+	// Roles are the roles of the allow or the deny set, as they are registered.
+	Roles []Role[T] `json:"-"`
+	// RoleNames are the names of the roles formatted by the role registry.
+	RoleNames []string `json:"roles,omitempty"`
+	// Permissions are the permissions required by the route.
+	Permissions []Permission `json:"permissions,omitempty"`
+	// Policy is the textual form of the policy.
+	Policy string `json:"policy,omitempty"`
+	// Handler is the name of the handler.
+	Handler string `json:"handler"`
+	// Shadowed is true, if the candidate policy of the route is evaluated in the report-only mode.
+	Shadowed bool `json:"shadowed,omitempty"`
+
+	policy *Policy[T]
+}
+
+// Protected checks if the route states a policy, the public routes are protected explicitly.
+func (r RouteRecord[T]) Protected() bool {
+	return r.Mode != ModeUnprotected
+}
+
+// Routes returns the records of all registered routes sorted by the path and the method.
+func (r *HttpRouter[T]) Routes() []RouteRecord[T] {
+	routes := r.routes()
+	records := make([]RouteRecord[T], 0, len(routes))
+	for _, e := range routes {
+		record := e.record
+		record.RoleNames = make([]string, 0, len(record.Roles))
+		for _, role := range record.Roles {
+			record.RoleNames = append(record.RoleNames, r.registry.Format(role.ID()))
+		}
+		if record.policy != nil {
+			record.Policy = record.policy.format(r.registry.Format)
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Path != records[j].Path {
+			return records[i].Path < records[j].Path
+		}
+		return records[i].Method < records[j].Method
+	})
+	return records
+}
+
+// routeEntry is the registered route, it is never changed after it is stored in the route table.
+type routeEntry[T RoleID] struct {
+	record     RouteRecord[T]
+	origin     http.Handler
+	handler    http.Handler
+	authorizer Authorizer[T]
+	shadow     *shadowPolicy[T]
+}
+
+// routeTable is the snapshot of the registered routes by the patterns.
+type routeTable[T RoleID] map[string]*routeEntry[T]
+
+// routes returns the current snapshot of the route table, the snapshot must not be changed.
+func (r *HttpRouter[T]) routes() routeTable[T] {
+	return *r.table.Load()
+}
+
+// update replaces the route table by the copy changed by the function.
+func (r *HttpRouter[T]) update(f func(t routeTable[T])) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.routes()
+	t := make(routeTable[T], len(current)+1)
+	for pattern, e := range current {
+		t[pattern] = e
+	}
+	f(t)
+	r.table.Store(&t)
+}
+
+// handlerName returns the name of the function or the type of the handler.
+func handlerName(handler interface{}) string {
+	v := reflect.ValueOf(handler)
+	if v.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", handler)
+}
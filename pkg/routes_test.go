@@ -0,0 +1,100 @@
+package rbacinjector
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHttpRouter_Routes(t *testing.T) {
+	g := NewRoleRegistry[uint64]()
+	if _, err := g.Register(iRoleAdmin.ID(), "ADMIN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Register(iRoleCustomer.ID(), "CUSTOMER"); err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetRoleRegistry(g)
+	route, err := router.NewRoute("accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncAllowFor("GET /{id}", httpStatusNoContent, iRoleCustomer, iRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncDenyFor("DELETE /{id}", httpStatusNoContent, iRoleCustomer); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncWithPolicy("POST /", httpStatusNoContent, Not[uint64](RoleSet[uint64](iRoleRoot))); err != nil {
+		t.Fatal(err)
+	}
+	if err = route.HandleFuncRequire("PUT /{id}", httpStatusNoContent, permInvoiceWrite); err != nil {
+		t.Fatal(err)
+	}
+	router.HandleFuncWithPolicy("GET /health", httpStatusNoContent, Public[uint64]())
+	if err = router.HandleFunc("/legacy", stubStatusOKHandle); err != nil {
+		t.Fatal(err)
+	}
+	if err = router.Wrap(http.NotFoundHandler()); err != nil {
+		t.Fatal(err)
+	}
+
+	routes := router.Routes()
+	if len(routes) != 7 {
+		t.Fatalf("unexpected routes %d", len(routes))
+	}
+
+	expected := []struct {
+		pattern   string
+		mode      Mode
+		roles     []string
+		protected bool
+	}{
+		{"", ModeUnprotected, []string{}, false},
+		{"POST /accounts", ModePolicy, []string{}, true},
+		{"DELETE /accounts/{id}", ModeDeny, []string{"CUSTOMER"}, true},
+		{"GET /accounts/{id}", ModeAllow, []string{"CUSTOMER", "ADMIN"}, true},
+		{"PUT /accounts/{id}", ModePermission, []string{}, true},
+		{"GET /health", ModePublic, []string{}, true},
+		{"/legacy", ModeUnprotected, []string{}, false},
+	}
+	for i, e := range expected {
+		r := routes[i]
+		if r.Pattern != e.pattern || r.Mode != e.mode || r.Protected() != e.protected {
+			t.Errorf("unexpected route %+v", r)
+		}
+		if !reflect.DeepEqual(r.RoleNames, e.roles) {
+			t.Errorf("unexpected roles %v for %s", r.RoleNames, r.Pattern)
+		}
+	}
+
+	if r := routes[1]; r.Policy != "not(roles(0x8000000000000000))" {
+		t.Errorf("unexpected policy %s", r.Policy)
+	}
+	if r := routes[3]; r.Method != http.MethodGet || r.Path != "/accounts/{id}" {
+		t.Errorf("unexpected route %+v", r.RouteInfo)
+	} else if !strings.HasSuffix(r.Handler, ".httpStatusNoContent") {
+		t.Errorf("unexpected handler %s", r.Handler)
+	}
+	if r := routes[4]; !reflect.DeepEqual(r.Permissions, []Permission{permInvoiceWrite}) {
+		t.Errorf("unexpected permissions %v", r.Permissions)
+	}
+	if r := routes[0]; r.Handler != "net/http.NotFound" {
+		t.Errorf("unexpected handler %s", r.Handler)
+	}
+
+	b, err := json.Marshal(routes[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); !strings.Contains(s, `"pattern":"GET /accounts/{id}","method":"GET","path":"/accounts/{id}","mode":"allow","roles":["CUSTOMER","ADMIN"]`) {
+		t.Errorf("unexpected json %s", s)
+	}
+}
@@ -0,0 +1,174 @@
+package rbacinjector
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ShadowReporter is the interface that wraps the basic Report method.
+// The Report method receives the decision of the candidate policy, that disagrees with the enforced decision.
+// The Report method is called before the request is served, it must not write the response.
+type ShadowReporter[T RoleID] interface {
+	Report(r *http.Request, enforced, candidate Decision[T])
+}
+
+// ShadowReporterFunc is an adapter to allow the use of ordinary functions as ShadowReporter.
+type ShadowReporterFunc[T RoleID] func(r *http.Request, enforced, candidate Decision[T])
+
+// Report calls f(r, enforced, candidate).
+func (f ShadowReporterFunc[T]) Report(r *http.Request, enforced, candidate Decision[T]) {
+	f(r, enforced, candidate)
+}
+
+// SetShadowReporter sets the reporter of the disagreements of the candidate policies.
+func (r *HttpRouter[T]) SetShadowReporter(reporter ShadowReporter[T]) {
+	r.shadowReporter = reporter
+}
+
+// ShadowAllowFor sets the candidate policy of the registered route, that allows the roles.
+// The candidate is evaluated in the report-only mode, the request is served under the enforced policy.
+// It returns ErrRouteNotFound if no route is registered for the pattern.
+func (r *HttpRouter[T]) ShadowAllowFor(pattern string, roles ...Role[T]) error {
+	return r.ShadowWithAuthorizer(pattern, r.rolesAuthorizer(ModeAllow, roles))
+}
+
+// ShadowDenyFor sets the candidate policy of the registered route, that denies the roles.
+// The candidate is evaluated in the report-only mode, the request is served under the enforced policy.
+// It returns ErrRouteNotFound if no route is registered for the pattern.
+func (r *HttpRouter[T]) ShadowDenyFor(pattern string, roles ...Role[T]) error {
+	return r.ShadowWithAuthorizer(pattern, r.rolesAuthorizer(ModeDeny, roles))
+}
+
+// ShadowWithPolicy sets the candidate policy of the registered route.
+// The candidate is evaluated in the report-only mode, the request is served under the enforced policy.
+// It returns ErrRouteNotFound if no route is registered for the pattern.
+func (r *HttpRouter[T]) ShadowWithPolicy(pattern string, policy Policy[T]) error {
+	if policy.op == policyPublic {
+		return r.shadow(map[string]*shadowPolicy[T]{pattern: {}})
+	}
+	validator := policy.compile(r.hierarchy)
+	return r.ShadowWithAuthorizer(pattern, newValidatorAuthorizer[T](validator, true, ReasonPolicyNotSatisfied, nil))
+}
+
+// ShadowWithAuthorizer sets the candidate authorizer of the registered route.
+// The candidate is evaluated in the report-only mode, the request is served under the enforced policy.
+// The nil authorizer removes the candidate of the route.
+// It returns ErrRouteNotFound if no route is registered for the pattern.
+func (r *HttpRouter[T]) ShadowWithAuthorizer(pattern string, authorizer Authorizer[T]) error {
+	if authorizer == nil {
+		return r.shadow(map[string]*shadowPolicy[T]{pattern: nil})
+	}
+	return r.shadow(map[string]*shadowPolicy[T]{pattern: {authorizer: authorizer}})
+}
+
+// ShadowPolicyFile sets the entries of the JSON policy file as the candidate policies of the registered routes.
+// The format is the one of LoadPolicyFile, the handler of the entry is ignored.
+// The candidates are evaluated in the report-only mode, the requests are served under the enforced policies.
+// It returns PolicyFileError with the line of the entry, that has no registered route,
+// no candidate is set in case of an error.
+func (r *HttpRouter[T]) ShadowPolicyFile(rd io.Reader) error {
+	bindings, err := r.decodePolicyFile(rd, nil)
+	if err != nil {
+		return err
+	}
+
+	index := patternIndex(r.routes())
+	candidates := make(map[string]*shadowPolicy[T], len(bindings))
+	for _, b := range bindings {
+		if _, ok := index[routeKey(b.pattern)]; !ok {
+			return bindingError(b, fmt.Errorf("%w: %s", ErrRouteNotFound, b.pattern))
+		}
+		authorizer, _ := r.protection(b)
+		candidates[b.pattern] = &shadowPolicy[T]{authorizer: authorizer}
+	}
+	return r.shadow(candidates)
+}
+
+// ClearShadows removes the candidate policies of all routes.
+func (r *HttpRouter[T]) ClearShadows() {
+	r.update(func(t routeTable[T]) {
+		for pattern, e := range t {
+			if e.shadow != nil {
+				t[pattern] = r.shadowEntry(pattern, e, nil)
+			}
+		}
+	})
+}
+
+// shadowPolicy is the candidate policy of the route, the nil authorizer grants any subject.
+type shadowPolicy[T RoleID] struct {
+	authorizer Authorizer[T]
+}
+
+// shadow sets the candidate policies by the patterns in a single table swap, the nil candidate is removed.
+func (r *HttpRouter[T]) shadow(candidates map[string]*shadowPolicy[T]) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.routes()
+	index := patternIndex(current)
+	t := make(routeTable[T], len(current))
+	for pattern, e := range current {
+		t[pattern] = e
+	}
+	for p, candidate := range candidates {
+		pattern, ok := index[routeKey(p)]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrRouteNotFound, p)
+		}
+		t[pattern] = r.shadowEntry(pattern, current[pattern], candidate)
+	}
+	r.table.Store(&t)
+	return nil
+}
+
+// shadowEntry returns a copy of the route entry with the candidate policy, the nil candidate is removed.
+// The handler of the entry reports the disagreements, then serves the request under the enforced decision.
+// The subject is extracted once for both decisions.
+func (r *HttpRouter[T]) shadowEntry(pattern string, e *routeEntry[T], candidate *shadowPolicy[T]) *routeEntry[T] {
+	record := e.record
+	record.Shadowed = candidate != nil
+	s := r.newEntry(pattern, e.origin, e.authorizer, record)
+	if candidate == nil {
+		return s
+	}
+
+	s.shadow = candidate
+	enforced, served := s.authorizer, s.handler
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		reporter := r.shadowReporter
+		if reporter == nil {
+			served.ServeHTTP(w, req)
+			return
+		}
+
+		start := time.Now()
+		roles, exists := r.extract(req)
+		subject := func(*http.Request) ([]Role[T], bool) { return roles, exists }
+		d := decide(subject, enforced, req, s.record.RouteInfo)
+		if enforced == nil {
+			d.Reason = openReason(s.record.Mode)
+		}
+		latency := time.Since(start)
+
+		c := decide(subject, candidate.authorizer, req, s.record.RouteInfo)
+		if d.Allowed != c.Allowed {
+			reporter.Report(req, d, c)
+		}
+		enforce(w, req, d, latency, s.origin.ServeHTTP, r.reject, r.observe)
+	})
+	return s
+}
+
+// patternIndex returns the registered patterns by the method and the path.
+func patternIndex[T RoleID](t routeTable[T]) map[string]string {
+	index := make(map[string]string, len(t))
+	for pattern := range t {
+		if pattern != "" {
+			index[routeKey(pattern)] = pattern
+		}
+	}
+	return index
+}
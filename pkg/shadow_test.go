@@ -0,0 +1,152 @@
+package rbacinjector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHttpRouter_ShadowAllowFor(t *testing.T) {
+	var mutex sync.Mutex
+	var reports []Decision[uint64]
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetShadowReporter(ShadowReporterFunc[uint64](func(r *http.Request, enforced, candidate Decision[uint64]) {
+		if !enforced.Allowed || candidate.Allowed {
+			t.Errorf("unexpected report %v %v", enforced, candidate)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		reports = append(reports, candidate)
+	}))
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin, iRoleCustomer)
+	if err = router.ShadowAllowFor("GET /accounts/{id}", iRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err = router.ShadowAllowFor("GET /invoices", iRoleAdmin); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	cases := []struct {
+		role     Role[uint64]
+		expected int
+	}{
+		{iRoleAdmin, http.StatusNoContent},
+		{iRoleCustomer, http.StatusNoContent},
+		{iRoleManager, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("unexpected status code %d", w.Code)
+		}
+	}
+
+	if len(reports) != 1 {
+		t.Fatalf("unexpected reports %v", reports)
+	}
+	if reports[0].Reason != ReasonNotInAllowSet || len(reports[0].Roles) != 1 || reports[0].Roles[0] != iRoleCustomer {
+		t.Errorf("unexpected report %v", reports[0])
+	}
+	if routes := router.Routes(); len(routes) != 1 || !routes[0].Shadowed {
+		t.Errorf("unexpected routes %v", routes)
+	}
+
+	router.ClearShadows()
+	if routes := router.Routes(); len(routes) != 1 || routes[0].Shadowed {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}
+
+func TestHttpRouter_ShadowExtractsOnce(t *testing.T) {
+	var calls int
+	router, err := NewHttpRouter[uint64](func(r *http.Request) (Role[uint64], bool) {
+		calls++
+		return extractorINT(r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := NewMetrics()
+	router.SetMetrics(metrics)
+	router.SetShadowReporter(ShadowReporterFunc[uint64](func(*http.Request, Decision[uint64], Decision[uint64]) {}))
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin, iRoleCustomer)
+	if err = router.ShadowAllowFor("GET /accounts/{id}", iRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleCustomer))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("unexpected extractor calls %d", calls)
+	}
+	if s := metrics.String(); !strings.Contains(s, "rbac_role_extraction_seconds_count 1\n") ||
+		!strings.Contains(s, `outcome="allowed"} 1`) {
+		t.Errorf("unexpected metrics %s", s)
+	}
+}
+
+func TestHttpRouter_ShadowPolicyFile(t *testing.T) {
+	var reports []string
+
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.SetShadowReporter(ShadowReporterFunc[uint64](func(r *http.Request, enforced, candidate Decision[uint64]) {
+		reports = append(reports, r.Method+" "+r.URL.Path+" "+string(candidate.Reason))
+	}))
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleAdmin, iRoleCustomer)
+	router.HandleFuncWithPolicy("GET /health", httpStatusNoContent, Public[uint64]())
+
+	err = router.ShadowPolicyFile(strings.NewReader(`[
+  {"pattern": "GET /accounts/{id}", "mode": "deny", "roles": ["CUSTOMER"]},
+  {"pattern": "GET /health", "mode": "allow", "roles": ["ADMIN"]}
+]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"/accounts/1", "/health"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleCustomer))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: unexpected status code %d", target, w.Code)
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code %d", w.Code)
+	}
+
+	expected := "GET /accounts/1 in deny set,GET /health not in allow set,GET /health no role"
+	if s := strings.Join(reports, ","); s != expected {
+		t.Errorf("unexpected reports %s", s)
+	}
+
+	err = router.ShadowPolicyFile(strings.NewReader(`[{"pattern": "GET /invoices", "mode": "deny", "roles": ["CUSTOMER"]}]`))
+	var fileErr *PolicyFileError
+	if !errors.As(err, &fileErr) || fileErr.Line != 1 {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package rbacinjector
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C Trace Context header, that carries the parent of the span.
+const TraceparentHeader = "traceparent"
+
+// SpanNameAuthorize is the name of the span of the authorization check.
+const SpanNameAuthorize = "authorize"
+
+// Span is the span of the authorization check, it covers the role extraction and the validation.
+type Span struct {
+	// TraceID is the hexadecimal ID of the trace, it is continued from the traceparent header, if any.
+	TraceID string
+	// SpanID is the hexadecimal ID of the span.
+	SpanID string
+	// ParentSpanID is the hexadecimal ID of the parent span from the traceparent header, if any.
+	ParentSpanID string
+	// Name is the name of the span.
+	Name string
+	// Start is the start time of the span.
+	Start time.Time
+	// End is the end time of the span.
+	End time.Time
+	// Attributes are the decision and the roles of the check.
+	Attributes map[string]string
+}
+
+// Traceparent returns the traceparent header value, that makes the span the parent of the downstream spans.
+func (s Span) Traceparent() string {
+	return "00-" + s.TraceID + "-" + s.SpanID + "-01"
+}
+
+// SpanExporter is the interface that wraps the basic ExportSpan method.
+// The ExportSpan method receives every sampled span of the authorization check.
+type SpanExporter interface {
+	ExportSpan(ctx context.Context, span Span)
+}
+
+// SpanExporterFunc is an adapter to allow the use of ordinary functions as SpanExporter.
+type SpanExporterFunc func(ctx context.Context, span Span)
+
+// ExportSpan calls f(ctx, span).
+func (f SpanExporterFunc) ExportSpan(ctx context.Context, span Span) {
+	f(ctx, span)
+}
+
+// SetSpanExporter sets the exporter of the spans of the authorization checks.
+// The requests with the traceparent header continue its trace, the requests whose traceparent
+// is not sampled are not exported, the other requests start a new trace.
+func (r *HttpRouter[T]) SetSpanExporter(exporter SpanExporter) {
+	r.spanExporter = exporter
+}
+
+// trace exports the span of the decision made for the request between the start and the end.
+func (r *HttpRouter[T]) trace(req *http.Request, d Decision[T], start, end time.Time) {
+	exporter := r.spanExporter
+	if exporter == nil {
+		return
+	}
+
+	traceID, parentID, sampled := parseTraceparent(req.Header.Get(TraceparentHeader))
+	if !sampled {
+		return
+	}
+	if traceID == "" {
+		traceID = randomHex(16)
+	}
+	span := Span{
+		TraceID:      traceID,
+		SpanID:       randomHex(8),
+		ParentSpanID: parentID,
+		Name:         SpanNameAuthorize,
+		Start:        start,
+		End:          end,
+		Attributes: map[string]string{
+			"http.method":  req.Method,
+			"http.route":   d.Route.Pattern,
+			"rbac.allowed": strconv.FormatBool(d.Allowed),
+			"rbac.reason":  string(d.Reason),
+			"rbac.status":  strconv.Itoa(d.Status()),
+			"rbac.role":    r.roleLabel(d.Roles),
+		},
+	}
+	exporter.ExportSpan(req.Context(), span)
+}
+
+// parseTraceparent returns the trace ID, the parent span ID and the sampled flag of the traceparent header.
+// The missing or the invalid header returns the empty IDs and the sampled flag, so a new trace is started.
+func parseTraceparent(header string) (traceID, parentID string, sampled bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || parts[0] == "ff" || len(parts[0]) != 2 || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", true
+	}
+	version, trace, parent, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version) || !isLowerHex(trace) || len(trace) != 32 || strings.Trim(trace, "0") == "" ||
+		!isLowerHex(parent) || len(parent) != 16 || strings.Trim(parent, "0") == "" ||
+		!isLowerHex(flags) || len(flags) != 2 {
+		return "", "", true
+	}
+	f, _ := strconv.ParseUint(flags, 16, 8)
+	return trace, parent, f&0x01 == 0x01
+}
+
+// isLowerHex checks if the string consists of the lowercase hexadecimal digits.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// randomHex returns the hexadecimal form of the n random bytes.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewInMemorySpanExporter returns a new InMemorySpanExporter.
+func NewInMemorySpanExporter() *InMemorySpanExporter {
+	return &InMemorySpanExporter{}
+}
+
+// InMemorySpanExporter is a SpanExporter that keeps the spans in memory, e.g. for the tests.
+// It is safe for concurrent use.
+type InMemorySpanExporter struct {
+	mutex sync.Mutex
+	spans []Span
+}
+
+// ExportSpan keeps the span.
+func (e *InMemorySpanExporter) ExportSpan(_ context.Context, span Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they are exported.
+func (e *InMemorySpanExporter) Spans() []Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]Span(nil), e.spans...)
+}
+
+// Reset removes the exported spans.
+func (e *InMemorySpanExporter) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,108 @@
+package rbacinjector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpRouter_SetSpanExporter(t *testing.T) {
+	exporter := NewInMemorySpanExporter()
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerStubRoles(t, router)
+	router.SetSpanExporter(exporter)
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+
+	cases := []struct {
+		traceparent string
+		role        Role[uint64]
+		traceID     string
+		parentID    string
+		allowed     string
+		reason      Reason
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", iRoleCustomer, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", "true", ReasonGranted},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", iRoleManager, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", "false", ReasonNotInAllowSet},
+		{"", nil, "", "", "false", ReasonNoRole},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", iRoleCustomer, "", "", "true", ReasonGranted},
+	}
+	for _, c := range cases {
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		if c.traceparent != "" {
+			req.Header.Set(TraceparentHeader, c.traceparent)
+		}
+		if c.role != nil {
+			req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, c.role))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.Spans()
+		if len(spans) != 1 {
+			t.Errorf("unexpected spans %v", spans)
+			continue
+		}
+		span := spans[0]
+		if span.Name != SpanNameAuthorize || span.ParentSpanID != c.parentID || len(span.SpanID) != 16 || len(span.TraceID) != 32 {
+			t.Errorf("unexpected span %+v", span)
+		}
+		if c.traceID != "" && span.TraceID != c.traceID {
+			t.Errorf("unexpected trace id %s", span.TraceID)
+		}
+		if span.Attributes["rbac.allowed"] != c.allowed || span.Attributes["rbac.reason"] != string(c.reason) ||
+			span.Attributes["http.route"] != "GET /accounts/{id}" {
+			t.Errorf("unexpected attributes %v", span.Attributes)
+		}
+		if span.End.Before(span.Start) {
+			t.Errorf("unexpected span time %v %v", span.Start, span.End)
+		}
+	}
+
+	exporter.Reset()
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if spans := exporter.Spans(); len(spans) != 0 {
+		t.Errorf("unexpected spans of the unsampled trace %v", spans)
+	}
+}
+
+func TestHttpRouter_SpanEndsBeforeAudit(t *testing.T) {
+	exporter := NewInMemorySpanExporter()
+	router, err := NewHttpRouter[uint64](extractorINT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.SetSpanExporter(exporter)
+	var audited time.Time
+	router.SetAuditHook(AuditHookFunc(func(context.Context, AuditRecord) {
+		time.Sleep(20 * time.Millisecond)
+		audited = time.Now()
+	}))
+	router.HandleFuncAllowFor("GET /accounts/{id}", httpStatusNoContent, iRoleCustomer)
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextRoleKey, iRoleCustomer))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+	if d := audited.Sub(spans[0].End); d < 20*time.Millisecond {
+		t.Errorf("unexpected span end %v before the audit", d)
+	}
+}
+
+func TestSpan_Traceparent(t *testing.T) {
+	span := Span{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	traceID, parentID, sampled := parseTraceparent(span.Traceparent())
+	if traceID != span.TraceID || parentID != span.SpanID || !sampled {
+		t.Errorf("unexpected traceparent %s", span.Traceparent())
+	}
+}